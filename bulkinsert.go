@@ -1,10 +1,15 @@
 package main
 
 import (
+	"fmt"
+	"go/token"
+	"maps"
+	"slices"
 	"strings"
-	"unicode"
 
 	"github.com/sqlc-dev/plugin-sdk-go/plugin"
+	"github.com/sqlc-dev/plugin-sdk-go/sdk"
+	helpers "github.com/tomtwinkle/process-plugin-sqlc-gen-bulk-go/templates"
 )
 
 type BulkInsert struct {
@@ -12,16 +17,125 @@ type BulkInsert struct {
 	QueryName string
 	// Go field names corresponding to the INSERT column order
 	ParamFieldNames []string
+	// RowType is the element type of the Bulk{{QueryName}}Params slice:
+	// the {{QueryName}}Params struct generated by sqlc, the Bulk{{QueryName}}Row struct generated by this plugin,
+	// or the Go type of the single parameter
+	RowType string
+	// RowFields are the fields of the Bulk{{QueryName}}Row struct.
+	// It is generated when sqlc passes the parameters as separate arguments and generates no {{QueryName}}Params struct
+	RowFields []StatementParam
+	// PointerRow is true when the elements of the Bulk{{QueryName}}Params slice are pointers to {{QueryName}}Params
+	PointerRow bool
+	// ScalarRow is true when sqlc passes the single parameter as a scalar argument;
+	// the elements of the Bulk{{QueryName}}Params slice are then the parameter values themselves
+	ScalarRow bool
+	// Parameters outside the VALUES clause (e.g., in "ON CONFLICT ... DO UPDATE SET"),
+	// bound once for all rows and passed to the bulk function as separate arguments
+	StatementParams []StatementParam
 	// Original SQL query string (for placeholder generation)
 	OriginalQuery string
+	// ConstantName is the name of the query string constant generated by sqlc
+	ConstantName string
+	// Cmd is the command of the original query, such as ":exec", ":execrows" or ":many"
+	Cmd string
+	// ReturnType is the type of the rows returned by a :one or :many query with a RETURNING clause,
+	// the same type as the sqlc-generated function returns. It is empty for the other queries
+	ReturnType string
+	// ScanDests are the scan destinations of a returned row, which are expressions on a variable "i" of ReturnType
+	ScanDests []string
+	// LastInsertIDs makes a MySQL :execlastid query return the IDs of all inserted rows
+	LastInsertIDs bool
+	// UnnestQuery is the query rewritten to insert the rows of one array per column (bulk_mode "unnest"),
+	// and UnnestConstantName the name of its constant. They are empty for the other queries
+	UnnestQuery        string
+	UnnestConstantName string
+	// UnnestColumns are the fields of a row bound as the arrays of UnnestQuery with their types;
+	// the name is empty for a scalar row
+	UnnestColumns []StatementParam
+	// CopyTable, CopyColumns and CopyFields are the table, the columns and the fields of a row
+	// of the BulkXxxCopy function (emit_copy_functions); a field is empty for a scalar row.
+	// CopyTable is nil for the queries that COPY cannot run
+	CopyTable   []string
+	CopyColumns []string
+	CopyFields  []string
+	// LoadDataTable, LoadDataColumns and LoadDataFields are the table, the columns and the fields of a row
+	// of the BulkXxxLoadData function (emit_load_data_functions), as CopyTable, CopyColumns and CopyFields are.
+	// LoadDataTable is nil for the queries that LOAD DATA cannot run
+	LoadDataTable   []string
+	LoadDataColumns []string
+	LoadDataFields  []string
+	// SeqFunctions generates the BulkXxxSeq and BulkXxxSeq2 functions (emit_seq_functions),
+	// which are not generated for the queries that return a value per row
+	SeqFunctions bool
+	// BulkWriter generates the XxxBulkWriter type (emit_bulk_writers),
+	// which is not generated for the queries that return a value per row
+	BulkWriter bool
+	// AsyncBulkWriter generates the XxxAsyncBulkWriter type (emit_async_bulk_writers) on the same condition as BulkWriter
+	AsyncBulkWriter bool
+	// BatchArgs are the arguments of the original statement for a row "arg" in the order of the parameter numbers,
+	// which the BulkXxxBatch function (emit_batch_functions) queues. They are nil for the queries without it
+	BatchArgs []string
+	// OrderedReturning makes the returned rows line up with the input rows (preserve_returning_order)
+	OrderedReturning bool
 }
 
 type BulkInserts []BulkInsert
 
+// hasLastInsertIDs reports whether any of the bulk inserts returns the IDs of the inserted rows.
+func (b BulkInserts) hasLastInsertIDs() bool {
+	return slices.ContainsFunc(b, func(bulkInsert BulkInsert) bool { return bulkInsert.LastInsertIDs })
+}
+
+// hasBatchFunctions reports whether any of the bulk inserts has a BulkXxxBatch function.
+func (b BulkInserts) hasBatchFunctions() bool {
+	return slices.ContainsFunc(b, func(bulkInsert BulkInsert) bool { return bulkInsert.BatchArgs != nil })
+}
+
+// hasSeqFunctions reports whether any of the bulk inserts has BulkXxxSeq functions.
+func (b BulkInserts) hasSeqFunctions() bool {
+	return slices.ContainsFunc(b, func(bulkInsert BulkInsert) bool { return bulkInsert.SeqFunctions })
+}
+
+// hasBulkWriters reports whether any of the bulk inserts has an XxxBulkWriter type.
+func (b BulkInserts) hasBulkWriters() bool {
+	return slices.ContainsFunc(b, func(bulkInsert BulkInsert) bool { return bulkInsert.BulkWriter })
+}
+
+// hasAsyncBulkWriters reports whether any of the bulk inserts has an XxxAsyncBulkWriter type.
+func (b BulkInserts) hasAsyncBulkWriters() bool {
+	return slices.ContainsFunc(b, func(bulkInsert BulkInsert) bool { return bulkInsert.AsyncBulkWriter })
+}
+
+// hasLoadDataFunctions reports whether any of the bulk inserts has a BulkXxxLoadData function.
+func (b BulkInserts) hasLoadDataFunctions() bool {
+	return slices.ContainsFunc(b, func(bulkInsert BulkInsert) bool { return bulkInsert.LoadDataTable != nil })
+}
+
+// hasCopyFunctions reports whether any of the bulk inserts has a BulkXxxCopy function.
+func (b BulkInserts) hasCopyFunctions() bool {
+	return slices.ContainsFunc(b, func(bulkInsert BulkInsert) bool { return bulkInsert.CopyTable != nil })
+}
+
+// hasCmd reports whether any of the bulk inserts is of the command cmd.
+func (b BulkInserts) hasCmd(cmd string) bool {
+	return slices.ContainsFunc(b, func(bulkInsert BulkInsert) bool { return bulkInsert.Cmd == cmd })
+}
+
+// StatementParam is an argument of a generated bulk function for a statement-level parameter,
+// or a field of a generated row struct.
+type StatementParam struct {
+	// Name is the Go argument or field name
+	Name string
+	// Type is the Go type of the argument or field
+	Type string
+}
+
 func buildBulkInsert(
-	req *plugin.GenerateRequest, _ *Options,
-) BulkInserts {
-	slices := make([]BulkInsert, 0)
+	req *plugin.GenerateRequest, opts *Options,
+) (BulkInserts, error) {
+	numberedPlaceholders := usesNumberedPlaceholders(req.GetSettings().GetEngine())
+	bulkInserts := make([]BulkInsert, 0)
+	var models []modelStruct
 	for _, query := range req.GetQueries() {
 		// For queries that are INSERT statements and of the type where sqlc generates a parameter structure
 		// If query.GetCmd() is an empty string, it may be different from something like a simple :exec
@@ -30,71 +144,346 @@ func buildBulkInsert(
 			continue
 		}
 
-		paramFieldNames := make([]string, 0, len(query.GetParams()))
+		// Sort the parameters into per-row parameters (in the VALUES clause) and statement-level parameters
+		rowNumbers, statementNumbers, err := helpers.InsertQueryPlaceholders(query.GetText(), numberedPlaceholders)
+		if err != nil {
+			// INSERT statements without a single-row VALUES clause (e.g., INSERT ... SELECT) cannot be expanded
+			continue
+		}
+		if slices.ContainsFunc(query.GetParams(), func(p *plugin.Parameter) bool {
+			return p.GetColumn().GetIsSqlcSlice()
+		}) {
+			// sqlc.slice parameters are expanded by the sqlc-generated function itself and cannot be expanded per row
+			continue
+		}
+
+		params := make(map[int]*plugin.Parameter, len(query.GetParams()))
 		for _, p := range query.GetParams() {
-			if p.GetColumn() == nil {
-				continue
+			params[int(p.GetNumber())] = p
+		}
+		for number := range params {
+			if !slices.Contains(rowNumbers, number) && !slices.Contains(statementNumbers, number) {
+				return nil, fmt.Errorf("query %s: parameter %d is not found in the query text", query.GetName(), number)
 			}
-			nameFromPlugin := p.GetColumn().GetName()
-			goFieldName := snakeToPascalCase(nameFromPlugin)
-			paramFieldNames = append(paramFieldNames, goFieldName)
 		}
 
-		// INSERT statements that fail to get any parameters are skipped (usually len(query.GetParams()) == 0)
-		if len(paramFieldNames) == 0 {
+		// INSERT statements whose VALUES clause has no parameters are skipped
+		if len(rowNumbers) == 0 {
 			continue
 		}
 
-		slices = append(slices, BulkInsert{
+		rowParams := make([]*plugin.Parameter, 0, len(rowNumbers))
+		for _, number := range rowNumbers {
+			p, ok := params[number]
+			if !ok {
+				return nil, fmt.Errorf("query %s: placeholder %d has no matching parameter", query.GetName(), number)
+			}
+			rowParams = append(rowParams, p)
+		}
+
+		statementParams := make([]StatementParam, 0, len(statementNumbers))
+		usedArgNames := make(map[string]bool, len(statementNumbers))
+		for _, number := range statementNumbers {
+			p, ok := params[number]
+			if !ok {
+				return nil, fmt.Errorf("query %s: placeholder %d has no matching parameter", query.GetName(), number)
+			}
+			name := fmt.Sprintf("dollar_%d", number)
+			if p.GetColumn().GetName() != "" {
+				name = p.GetColumn().GetName()
+			}
+			argName := uniqueArgName(name, number, usedArgNames, opts)
+			usedArgNames[argName] = true
+			statementParams = append(statementParams, StatementParam{Name: argName, Type: paramGoType(req, opts, p)})
+		}
+
+		bulkInsert := BulkInsert{
 			QueryName:       query.GetName(),
-			ParamFieldNames: paramFieldNames,
+			StatementParams: statementParams,
 			OriginalQuery:   query.GetText(),
-		})
+			ConstantName:    queryConstantName(query.GetName(), opts),
+			Cmd:             query.GetCmd(),
+			LastInsertIDs:   query.GetCmd() == ":execlastid" && req.GetSettings().GetEngine() == "mysql",
+		}
+		if (query.GetCmd() == ":one" || query.GetCmd() == ":many") && len(query.GetColumns()) > 0 {
+			// The rows returned by RETURNING are scanned into the type that sqlc-gen-go uses
+			if models == nil {
+				models = buildModelStructs(req, opts)
+			}
+			bulkInsert.ReturnType, bulkInsert.ScanDests = buildReturning(req, opts, query, models)
+			bulkInsert.OrderedReturning = opts.PreserveReturningOrder
+		}
+		// The same rule as sqlc-gen-go decides how the parameters are passed to the sqlc-generated function
+		queryParameterLimit := int(*opts.QueryParameterLimit)
+		if len(query.GetParams()) == 1 && queryParameterLimit != 0 {
+			// A single parameter is passed as a scalar argument
+			bulkInsert.RowType = paramGoType(req, opts, rowParams[0])
+			bulkInsert.ScalarRow = true
+		} else {
+			fieldNames, err := resolveParamFieldNames(query, opts)
+			if err != nil {
+				return nil, err
+			}
+			bulkInsert.ParamFieldNames = make([]string, 0, len(rowParams))
+			for _, p := range rowParams {
+				bulkInsert.ParamFieldNames = append(bulkInsert.ParamFieldNames, fieldNames[int(p.GetNumber())])
+			}
+			switch {
+			case len(query.GetParams()) <= queryParameterLimit:
+				// The parameters are passed as separate arguments and sqlc generates no XxxParams struct
+				bulkInsert.RowType = "Bulk" + query.GetName() + "Row"
+				bulkInsert.RowFields = make([]StatementParam, 0, len(rowParams))
+				for i, p := range rowParams {
+					if slices.ContainsFunc(bulkInsert.RowFields, func(f StatementParam) bool {
+						return f.Name == bulkInsert.ParamFieldNames[i]
+					}) {
+						// A named parameter used more than once is a single field
+						continue
+					}
+					bulkInsert.RowFields = append(bulkInsert.RowFields, StatementParam{
+						Name: bulkInsert.ParamFieldNames[i],
+						Type: paramGoType(req, opts, p),
+					})
+				}
+			case opts.EmitParamsStructPointers:
+				bulkInsert.RowType = "*" + query.GetName() + "Params"
+				bulkInsert.PointerRow = true
+			default:
+				bulkInsert.RowType = query.GetName() + "Params"
+			}
+		}
+		if opts.BulkMode == bulkModeUnnest && !bulkInsert.OrderedReturning {
+			bulkInsert.UnnestQuery, bulkInsert.UnnestColumns = buildUnnest(req, opts, query, rowParams, bulkInsert)
+			if bulkInsert.UnnestQuery != "" {
+				bulkInsert.UnnestConstantName = "bulkUnnest" + query.GetName()
+			}
+		}
+		// The rows of a chunk are not returned by the functions that insert rows in chunks of their own
+		perRowResult := bulkInsert.ReturnType != "" || bulkInsert.LastInsertIDs
+		bulkInsert.SeqFunctions = opts.EmitSeqFunctions && !perRowResult
+		bulkInsert.BulkWriter = opts.EmitBulkWriters && !perRowResult
+		bulkInsert.AsyncBulkWriter = opts.EmitAsyncBulkWriters && !perRowResult
+		if opts.EmitBatchFunctions && bulkInsert.ReturnType == "" {
+			// The statement-level parameters are bound as the arguments of the same name
+			numbers := slices.Sorted(maps.Keys(params))
+			bulkInsert.BatchArgs = make([]string, 0, len(numbers))
+			for _, number := range numbers {
+				switch i := slices.Index(rowNumbers, number); {
+				case i < 0:
+					bulkInsert.BatchArgs = append(bulkInsert.BatchArgs, statementParams[slices.Index(statementNumbers, number)].Name)
+				case bulkInsert.ScalarRow:
+					bulkInsert.BatchArgs = append(bulkInsert.BatchArgs, "arg")
+				default:
+					bulkInsert.BatchArgs = append(bulkInsert.BatchArgs, "arg."+bulkInsert.ParamFieldNames[i])
+				}
+			}
+		}
+		if opts.EmitCopyFunctions {
+			bulkInsert.CopyTable, bulkInsert.CopyColumns, bulkInsert.CopyFields = buildRowColumns(
+				req, query, rowNumbers, bulkInsert, helpers.CopyFromColumns,
+			)
+		}
+		if opts.EmitLoadDataFunctions {
+			bulkInsert.LoadDataTable, bulkInsert.LoadDataColumns, bulkInsert.LoadDataFields = buildRowColumns(
+				req, query, rowNumbers, bulkInsert, helpers.LoadDataColumns,
+			)
+		}
+		bulkInserts = append(bulkInserts, bulkInsert)
 	}
-	return slices
+	return bulkInserts, nil
 }
 
-// snakeToPascalCase converts a snake case string to a Pascal case.
-// certain words such as "id" are treated as uppercase, as in "ID".
-// Example: "user_id" -> "UserID", "email" -> "Email"
-func snakeToPascalCase(snakeStr string) string {
-	if snakeStr == "" {
-		return ""
+// buildRowColumns returns the table and the columns of a query for pgx CopyFrom or MySQL LOAD DATA
+// and the field of a row bound to each column. rowColumns is helpers.CopyFromColumns or helpers.LoadDataColumns.
+// The columns are taken from the column list of the query, or from the catalog if it has none.
+// It returns a nil table if the query cannot be run so, e.g., an upsert or a row with expressions.
+func buildRowColumns(
+	req *plugin.GenerateRequest, query *plugin.Query, rowNumbers []int, bulkInsert BulkInsert,
+	rowColumns func(originalQuery string) ([]string, []int, error),
+) ([]string, []string, []string) {
+	table := query.GetInsertIntoTable()
+	if table.GetName() == "" {
+		return nil, nil, nil
 	}
-
-	// map a specific word to the expected capitalization.
-	// This map can be extended as needed.
-	// (Keys are written in lowercase and are also converted to lowercase for comparison and matching)
-	commonInitialisms := map[string]string{
-		"id":   "ID",
-		"url":  "URL",
-		"api":  "API",
-		"json": "JSON",
-		"xml":  "XML",
-		"html": "HTML",
+	columns, numbers, err := rowColumns(query.GetText())
+	if err != nil {
+		return nil, nil, nil
+	}
+	if columns == nil {
+		columns = catalogColumnNames(req, table)
+		if len(columns) != len(numbers) {
+			return nil, nil, nil
+		}
 	}
 
-	var result strings.Builder
-	parts := strings.SplitSeq(snakeStr, "_")
-
-	for part := range parts {
-		if part == "" {
+	fields := make([]string, 0, len(numbers))
+	for _, number := range numbers {
+		if bulkInsert.ScalarRow {
+			fields = append(fields, "")
 			continue
 		}
+		fields = append(fields, bulkInsert.ParamFieldNames[slices.Index(rowNumbers, number)])
+	}
+	tableName := []string{table.GetName()}
+	if table.GetSchema() != "" {
+		tableName = []string{table.GetSchema(), table.GetName()}
+	}
+	return tableName, columns, fields
+}
 
-		// Check if the whole part matches the lower case key of the common acronym
-		// (e.g., match as "id" even if part is "id", "Id", "iD", or "ID")
-		if initialism, ok := commonInitialisms[strings.ToLower(part)]; ok {
-			result.WriteString(initialism)
-		} else {
-			runes := []rune(part)
-			if len(runes) > 0 {
-				result.WriteRune(unicode.ToUpper(runes[0]))
-				if len(runes) > 1 {
-					result.WriteString(string(runes[1:]))
-				}
+// catalogColumnNames returns the names of the columns of a table in the catalog, or nil if it is not found.
+func catalogColumnNames(req *plugin.GenerateRequest, table *plugin.Identifier) []string {
+	schemaName := table.GetSchema()
+	if schemaName == "" {
+		schemaName = req.GetCatalog().GetDefaultSchema()
+	}
+	for _, schema := range req.GetCatalog().GetSchemas() {
+		if schema.GetName() != schemaName {
+			continue
+		}
+		for _, t := range schema.GetTables() {
+			if t.GetRel().GetName() != table.GetName() {
+				continue
+			}
+			names := make([]string, 0, len(t.GetColumns()))
+			for _, col := range t.GetColumns() {
+				names = append(names, col.GetName())
 			}
+			return names
+		}
+	}
+	return nil
+}
+
+// namedByteSliceTypes are the Go types of parameters that are named byte slices.
+// pq.Array treats a slice of them as a two-dimensional array, because they are neither []byte nor driver.Valuer.
+var namedByteSliceTypes = map[string]bool{
+	"json.RawMessage": true,
+}
+
+// serialArrayTypes are the element types of the arrays bound to serial columns,
+// since serial types are not real types and have no array types.
+var serialArrayTypes = map[string]string{
+	"smallserial":        "int2",
+	"serial2":            "int2",
+	"pg_catalog.serial2": "int2",
+	"serial":             "int4",
+	"serial4":            "int4",
+	"pg_catalog.serial4": "int4",
+	"bigserial":          "int8",
+	"serial8":            "int8",
+	"pg_catalog.serial8": "int8",
+}
+
+// buildUnnest returns the query rewritten with unnest and the columns bound as its arrays.
+// It returns an empty query, so that the VALUES clause is expanded instead,
+// if the type of a row parameter is unknown or an array, which unnest would flatten, or the row cannot be selected.
+// It also falls back for named byte slices such as json.RawMessage with database/sql,
+// since pq.Array encodes their elements as nested arrays of bytes instead of text.
+func buildUnnest(
+	req *plugin.GenerateRequest, opts *Options, query *plugin.Query, rowParams []*plugin.Parameter, bulkInsert BulkInsert,
+) (string, []StatementParam) {
+	columnTypes := make([]string, 0, len(rowParams))
+	columns := make([]StatementParam, 0, len(rowParams))
+	for i, p := range rowParams {
+		col := p.GetColumn()
+		if col.GetType() == nil || col.GetIsArray() {
+			return "", nil
+		}
+		sqlType := sdk.DataType(col.GetType())
+		if sqlType == "" || sqlType == "any" {
+			return "", nil
 		}
+		if arrayType, ok := serialArrayTypes[sqlType]; ok {
+			sqlType = arrayType
+		}
+		columnTypes = append(columnTypes, sqlType)
+		column := StatementParam{Type: bulkInsert.RowType}
+		if !bulkInsert.ScalarRow {
+			column = StatementParam{Name: bulkInsert.ParamFieldNames[i], Type: paramGoType(req, opts, p)}
+		}
+		if namedByteSliceTypes[column.Type] && !opts.usesPgx() {
+			return "", nil
+		}
+		columns = append(columns, column)
+	}
+	unnestQuery, err := helpers.UnnestInsertQuery(query.GetText(), columnTypes)
+	if err != nil {
+		return "", nil
+	}
+	return unnestQuery, columns
+}
+
+// resolveParamFieldNames returns the names of the fields that sqlc-gen-go generates in the XxxParams struct
+// for the parameters of a query, keyed by the parameter number.
+// It mirrors columnsToStruct of sqlc-gen-go:
+// a parameter without a column name (e.g., an expression) is named "column_N" after its position,
+// and an unnamed parameter whose name is already used gets a "_2", "_3", ... suffix.
+// Parameters named with sqlc.arg / sqlc.narg / @name are identified by their name and are never suffixed,
+// so the numbers of a named parameter used more than once map to the same field.
+func resolveParamFieldNames(query *plugin.Query, opts *Options) (map[int]string, error) {
+	fieldNames := make(map[int]string, len(query.GetParams()))
+	numbers := make(map[string]int, len(query.GetParams()))
+	namedNumbers := make(map[int]bool, len(query.GetParams()))
+	seen := make(map[string]int, len(query.GetParams()))
+	for i, p := range query.GetParams() {
+		colName := p.GetColumn().GetName()
+		if colName == "" {
+			colName = fmt.Sprintf("column_%d", i+1)
+		}
+
+		fieldName := structName(colName, opts)
+		baseFieldName := fieldName
+		if n := seen[baseFieldName]; n > 0 && !p.GetColumn().GetIsNamedParam() {
+			fieldName = fmt.Sprintf("%s_%d", fieldName, n+1)
+		}
+		seen[baseFieldName]++
+
+		if !token.IsIdentifier(fieldName) || !token.IsExported(fieldName) {
+			return nil, fmt.Errorf("query %s: parameter %d (%s) maps to an invalid field name %q",
+				query.GetName(), p.GetNumber(), colName, fieldName)
+		}
+		// MySQL and SQLite give each use of a named parameter its own number, which share the field
+		if number, ok := numbers[fieldName]; ok && number != int(p.GetNumber()) &&
+			!(p.GetColumn().GetIsNamedParam() && namedNumbers[number]) {
+			return nil, fmt.Errorf("query %s: parameters %d and %d map to the same field %s",
+				query.GetName(), number, p.GetNumber(), fieldName)
+		}
+		numbers[fieldName] = int(p.GetNumber())
+		namedNumbers[int(p.GetNumber())] = p.GetColumn().GetIsNamedParam()
+		fieldNames[int(p.GetNumber())] = fieldName
+	}
+	return fieldNames, nil
+}
+
+// queryConstantName returns the name of the query string constant that sqlc-gen-go generates,
+// which is exported with emit_exported_queries.
+func queryConstantName(queryName string, opts *Options) string {
+	if opts.EmitExportedQueries {
+		return sdk.Title(queryName)
+	}
+	return sdk.LowerTitle(queryName)
+}
+
+// paramGoType returns the Go type of a parameter; a parameter without a column becomes "any".
+func paramGoType(req *plugin.GenerateRequest, opts *Options, p *plugin.Parameter) string {
+	if p.GetColumn() == nil {
+		return "any"
+	}
+	return goType(req, opts, p.GetColumn())
+}
+
+// uniqueArgName converts the column name of the statement-level parameter number to an argument name
+// with statementArgName, and appends "_2", "_3", ... while it is already used.
+// A name that is not a valid identifier, e.g., renamed to one, is replaced by one after the parameter number.
+func uniqueArgName(name string, number int, used map[string]bool, opts *Options) string {
+	baseName := statementArgName(name, opts)
+	if !token.IsIdentifier(baseName) {
+		baseName = statementArgName(fmt.Sprintf("dollar_%d", number), opts)
+	}
+	argName := baseName
+	for n := 2; used[argName]; n++ {
+		argName = fmt.Sprintf("%s_%d", baseName, n)
 	}
-	return result.String()
+	return argName
 }
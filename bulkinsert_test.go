@@ -3,68 +3,136 @@ package main
 import (
 	"testing"
 
+	"github.com/sqlc-dev/plugin-sdk-go/plugin"
 	"gotest.tools/v3/assert"
 )
 
-func Test_snakeToPascalCase(t *testing.T) {
+func Test_resolveParamFieldNames(t *testing.T) {
 	t.Parallel()
 	tests := map[string]struct {
-		input string
-		want  string
+		params []*plugin.Parameter
+		want   map[int]string
+		err    string
 	}{
-		"empty": {
-			input: "",
-			want:  "",
+		"column names": {
+			params: []*plugin.Parameter{
+				{Number: 1, Column: &plugin.Column{Name: "id"}},
+				{Number: 2, Column: &plugin.Column{Name: "user_name"}},
+			},
+			want: map[int]string{1: "ID", 2: "UserName"},
 		},
-		"simple": {
-			// "name" -> "Name"
-			input: "name",
-			want:  "Name",
+		"sqlc.arg": {
+			// INSERT INTO books (author_id, title) VALUES (sqlc.arg(author), $2)
+			params: []*plugin.Parameter{
+				{Number: 1, Column: &plugin.Column{Name: "author", IsNamedParam: true}},
+				{Number: 2, Column: &plugin.Column{Name: "title"}},
+			},
+			want: map[int]string{1: "Author", 2: "Title"},
 		},
-		"standard snake case": {
-			// "user_name" -> "UserName"
-			input: "user_name",
-			want:  "UserName",
+		"expression without column name": {
+			// INSERT INTO events (id, at) VALUES ($1, $2 + interval '1 day')
+			params: []*plugin.Parameter{
+				{Number: 1, Column: &plugin.Column{Name: "id"}},
+				{Number: 2, Column: &plugin.Column{}},
+			},
+			want: map[int]string{1: "ID", 2: "Column2"},
 		},
-		"common initialism (id)": {
-			// "id" -> "ID"
-			input: "user_id",
-			want:  "UserID",
+		"no column": {
+			params: []*plugin.Parameter{
+				{Number: 1},
+				{Number: 2, Column: &plugin.Column{Name: "name"}},
+			},
+			want: map[int]string{1: "Column1", 2: "Name"},
 		},
-		"common initialism (id) at start": {
-			input: "id_value",
-			want:  "IDValue",
+		"duplicate unnamed parameters": {
+			// INSERT INTO users (id, name) VALUES ($1, $2) ON CONFLICT (id) DO UPDATE SET name = $3
+			params: []*plugin.Parameter{
+				{Number: 1, Column: &plugin.Column{Name: "id"}},
+				{Number: 2, Column: &plugin.Column{Name: "name"}},
+				{Number: 3, Column: &plugin.Column{Name: "name"}},
+				{Number: 4, Column: &plugin.Column{Name: "name"}},
+			},
+			want: map[int]string{1: "ID", 2: "Name", 3: "Name_2", 4: "Name_3"},
 		},
-		"common initialism (url)": {
-			// "url" -> "URL"
-			input: "profile_url",
-			want:  "ProfileURL",
+		"colliding names after conversion": {
+			// "user_id", "userId" and "user-id" are different columns that sqlc-gen-go names
+			// "UserID", "UserId" and "UserID_2"
+			params: []*plugin.Parameter{
+				{Number: 1, Column: &plugin.Column{Name: "user_id"}},
+				{Number: 2, Column: &plugin.Column{Name: "userId"}},
+				{Number: 3, Column: &plugin.Column{Name: "user-id"}},
+				{Number: 4, Column: &plugin.Column{Name: "id"}},
+				{Number: 5, Column: &plugin.Column{Name: "id"}},
+			},
+			want: map[int]string{1: "UserID", 2: "UserId", 3: "UserID_2", 4: "ID", 5: "ID_2"},
 		},
-		"common initialism (json)": {
-			// "json" -> "JSON"
-			input: "json_data",
-			want:  "JSONData",
+		"named parameter used more than once": {
+			// INSERT INTO tags (name, note) VALUES (sqlc.arg(name), ?) ON DUPLICATE KEY UPDATE name = sqlc.arg(name)
+			params: []*plugin.Parameter{
+				{Number: 1, Column: &plugin.Column{Name: "name", IsNamedParam: true}},
+				{Number: 2, Column: &plugin.Column{Name: "note"}},
+				{Number: 3, Column: &plugin.Column{Name: "name", IsNamedParam: true}},
+			},
+			want: map[int]string{1: "Name", 2: "Note", 3: "Name"},
 		},
-		"double underscore": {
-			// "user__id" -> "UserID"
-			input: "user__id",
-			want:  "UserID",
+		"named parameter after an unnamed one with the same name": {
+			params: []*plugin.Parameter{
+				{Number: 1, Column: &plugin.Column{Name: "name"}},
+				{Number: 2, Column: &plugin.Column{Name: "name", IsNamedParam: true}},
+			},
+			err: "parameters 1 and 2 map to the same field Name",
 		},
-		"leading underscore": {
-			// "_user_name" -> "UserName"
-			input: "_user_name",
-			want:  "UserName",
+		"invalid field name": {
+			params: []*plugin.Parameter{
+				{Number: 1, Column: &plugin.Column{Name: "名前"}},
+			},
+			err: `parameter 1 (名前) maps to an invalid field name "名前"`,
 		},
-		"trailing underscore": {
-			// "user_name_" -> "UserName"
-			input: "user_name_",
-			want:  "UserName",
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			got, err := resolveParamFieldNames(
+				&plugin.Query{Name: "Insert", Params: tt.params}, newTestOptions(t, `{"package": "db"}`))
+			if tt.err != "" {
+				assert.ErrorContains(t, err, tt.err)
+				return
+			}
+			assert.NilError(t, err)
+			assert.DeepEqual(t, got, tt.want)
+		})
+	}
+}
+
+func Test_uniqueArgName(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		name          string
+		pluginOptions string
+		used          map[string]bool
+		want          string
+	}{
+		"column name": {
+			name:          "updated_by",
+			pluginOptions: `{"package": "db"}`,
+			want:          "stmtUpdatedBy",
+		},
+		"already used": {
+			name:          "updated-by",
+			pluginOptions: `{"package": "db"}`,
+			used:          map[string]bool{"stmtUpdatedBy": true, "stmtUpdatedBy_2": true},
+			want:          "stmtUpdatedBy_3",
+		},
+		"renamed to an invalid identifier": {
+			name:          "updated_by",
+			pluginOptions: `{"package": "db", "rename": {"updated_by": "Updated By"}}`,
+			want:          "stmtDollar3",
 		},
 	}
 	for name, tt := range tests {
 		t.Run(name, func(t *testing.T) {
 			t.Parallel()
-			got := snakeToPascalCase(tt.input)
+			got := uniqueArgName(tt.name, 3, tt.used, newTestOptions(t, tt.pluginOptions))
 			assert.Equal(t, got, tt.want)
 		})
 	}
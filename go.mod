@@ -3,6 +3,7 @@ module github.com/tomtwinkle/process-plugin-sqlc-gen-bulk-go
 go 1.25.0
 
 require (
+	github.com/jinzhu/inflection v1.0.0
 	github.com/sqlc-dev/plugin-sdk-go v1.23.0
 	gotest.tools/v3 v3.5.2
 )
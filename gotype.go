@@ -0,0 +1,438 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/sqlc-dev/plugin-sdk-go/plugin"
+	"github.com/sqlc-dev/plugin-sdk-go/sdk"
+)
+
+// goType returns the Go type that sqlc-gen-go generates for a column.
+// The mapping follows sqlc-gen-go (internal/codegen/golang/*_type.go); types that it does not know become "any".
+// The overrides of a column take precedence, and then the overrides of its database type.
+func goType(req *plugin.GenerateRequest, opts *Options, col *plugin.Column) string {
+	for _, override := range opts.Overrides {
+		if override.goTypeName == "" || !override.matchesColumn(req, col) {
+			continue
+		}
+		if col.GetIsSqlcSlice() {
+			return "[]" + override.goTypeName
+		}
+		return override.goTypeName
+	}
+	typ := goInnerType(req, opts, col)
+	if col.GetIsSqlcSlice() {
+		return "[]" + typ
+	}
+	if col.GetIsArray() {
+		return strings.Repeat("[]", int(col.GetArrayDims())) + typ
+	}
+	return typ
+}
+
+func goInnerType(req *plugin.GenerateRequest, opts *Options, col *plugin.Column) string {
+	for _, override := range opts.Overrides {
+		if override.goTypeName != "" && override.matchesDBType(col) {
+			return override.goTypeName
+		}
+	}
+	switch req.GetSettings().GetEngine() {
+	case "mysql":
+		return mysqlGoType(req, opts, col)
+	case "postgresql":
+		return postgresGoType(req, opts, col)
+	case "sqlite":
+		return sqliteGoType(opts, col)
+	default:
+		return "any"
+	}
+}
+
+func mysqlGoType(req *plugin.GenerateRequest, opts *Options, col *plugin.Column) string {
+	columnType := sdk.DataType(col.GetType())
+	notNull := col.GetNotNull() || col.GetIsArray()
+	unsigned := col.GetUnsigned()
+
+	switch columnType {
+	case "varchar", "text", "char", "tinytext", "mediumtext", "longtext":
+		if notNull {
+			return "string"
+		}
+		return "sql.NullString"
+	case "tinyint":
+		if col.GetLength() == 1 {
+			if notNull {
+				return "bool"
+			}
+			return "sql.NullBool"
+		}
+		if notNull {
+			if unsigned {
+				return "uint8"
+			}
+			return "int8"
+		}
+		// The database/sql package does not have a sql.NullInt8 type
+		return "sql.NullInt16"
+	case "year":
+		if notNull {
+			return "int16"
+		}
+		return "sql.NullInt16"
+	case "smallint":
+		if notNull {
+			if unsigned {
+				return "uint16"
+			}
+			return "int16"
+		}
+		return "sql.NullInt16"
+	case "int", "integer", "mediumint":
+		if notNull {
+			if unsigned {
+				return "uint32"
+			}
+			return "int32"
+		}
+		return "sql.NullInt32"
+	case "bigint":
+		if notNull {
+			if unsigned {
+				return "uint64"
+			}
+			return "int64"
+		}
+		return "sql.NullInt64"
+	case "blob", "binary", "varbinary", "tinyblob", "mediumblob", "longblob":
+		if notNull {
+			return "[]byte"
+		}
+		return "sql.NullString"
+	case "double", "double precision", "real", "float":
+		if notNull {
+			return "float64"
+		}
+		return "sql.NullFloat64"
+	case "decimal", "dec", "fixed":
+		if notNull {
+			return "string"
+		}
+		return "sql.NullString"
+	case "enum":
+		return "string"
+	case "date", "timestamp", "datetime", "time":
+		if notNull {
+			return "time.Time"
+		}
+		return "sql.NullTime"
+	case "boolean", "bool":
+		if notNull {
+			return "bool"
+		}
+		return "sql.NullBool"
+	case "json":
+		return "json.RawMessage"
+	case "any":
+		return "any"
+	}
+
+	for _, schema := range req.GetCatalog().GetSchemas() {
+		for _, enum := range schema.GetEnums() {
+			if enum.GetName() != columnType {
+				continue
+			}
+			enumName := enum.GetName()
+			if schema.GetName() != req.GetCatalog().GetDefaultSchema() {
+				enumName = schema.GetName() + "_" + enumName
+			}
+			if notNull {
+				return structName(enumName, opts)
+			}
+			return "Null" + structName(enumName, opts)
+		}
+	}
+	return "any"
+}
+
+func postgresGoType(req *plugin.GenerateRequest, opts *Options, col *plugin.Column) string {
+	columnType := sdk.DataType(col.GetType())
+	notNull := col.GetNotNull() || col.GetIsArray()
+	pgx := opts.usesPgx()
+	pgxV5 := opts.SqlPackage == sqlPackagePgxV5
+	emitPointersForNull := pgx && opts.EmitPointersForNullTypes
+
+	// nullable returns typ for NOT NULL columns, a pointer to typ with emit_pointers_for_null_types,
+	// and otherwise pgxV5Type with pgx/v5 or nullType with the other sql packages
+	nullable := func(typ, pgxV5Type, nullType string) string {
+		switch {
+		case notNull:
+			return typ
+		case emitPointersForNull:
+			return "*" + typ
+		case pgxV5:
+			return pgxV5Type
+		default:
+			return nullType
+		}
+	}
+	// bySQLPackage returns the type for pgx/v5, pgx/v4 or database/sql
+	bySQLPackage := func(pgxV5Type, pgxV4Type, stdType string) string {
+		switch opts.SqlPackage {
+		case sqlPackagePgxV5:
+			return pgxV5Type
+		case sqlPackagePgxV4:
+			return pgxV4Type
+		default:
+			return stdType
+		}
+	}
+
+	switch columnType {
+	case "serial", "serial4", "pg_catalog.serial4",
+		"integer", "int", "int4", "pg_catalog.int4":
+		return nullable("int32", "pgtype.Int4", "sql.NullInt32")
+	case "bigserial", "serial8", "pg_catalog.serial8",
+		"bigint", "int8", "pg_catalog.int8":
+		return nullable("int64", "pgtype.Int8", "sql.NullInt64")
+	case "smallserial", "serial2", "pg_catalog.serial2",
+		"smallint", "int2", "pg_catalog.int2":
+		return nullable("int16", "pgtype.Int2", "sql.NullInt16")
+	case "float", "double precision", "float8", "pg_catalog.float8":
+		return nullable("float64", "pgtype.Float8", "sql.NullFloat64")
+	case "real", "float4", "pg_catalog.float4":
+		return nullable("float32", "pgtype.Float4", "sql.NullFloat64")
+	case "numeric", "pg_catalog.numeric", "money":
+		if pgx {
+			return "pgtype.Numeric"
+		}
+		// lib/pq returns numerics as strings
+		return nullable("string", "", "sql.NullString")
+	case "boolean", "bool", "pg_catalog.bool":
+		return nullable("bool", "pgtype.Bool", "sql.NullBool")
+	case "json", "pg_catalog.json":
+		return bySQLPackage("[]byte", "pgtype.JSON", nullable("json.RawMessage", "", "pqtype.NullRawMessage"))
+	case "jsonb", "pg_catalog.jsonb":
+		return bySQLPackage("[]byte", "pgtype.JSONB", nullable("json.RawMessage", "", "pqtype.NullRawMessage"))
+	case "bytea", "blob", "pg_catalog.bytea":
+		return "[]byte"
+	case "date":
+		if pgxV5 {
+			return "pgtype.Date"
+		}
+		return nullable("time.Time", "", "sql.NullTime")
+	case "pg_catalog.time":
+		if pgxV5 {
+			return "pgtype.Time"
+		}
+		return nullable("time.Time", "", "sql.NullTime")
+	case "pg_catalog.timetz":
+		return nullable("time.Time", "sql.NullTime", "sql.NullTime")
+	case "pg_catalog.timestamp", "timestamp":
+		if pgxV5 {
+			return "pgtype.Timestamp"
+		}
+		return nullable("time.Time", "", "sql.NullTime")
+	case "pg_catalog.timestamptz", "timestamptz":
+		if pgxV5 {
+			return "pgtype.Timestamptz"
+		}
+		return nullable("time.Time", "", "sql.NullTime")
+	case "text", "pg_catalog.varchar", "pg_catalog.bpchar", "string", "citext", "name",
+		"ltree", "lquery", "ltxtquery":
+		return nullable("string", "pgtype.Text", "sql.NullString")
+	case "uuid":
+		if pgxV5 {
+			return "pgtype.UUID"
+		}
+		return nullable("uuid.UUID", "", "uuid.NullUUID")
+	case "inet":
+		return bySQLPackage(nullable("netip.Addr", "*netip.Addr", ""), "pgtype.Inet", "pqtype.Inet")
+	case "cidr":
+		return bySQLPackage(nullable("netip.Prefix", "*netip.Prefix", ""), "pgtype.CIDR", "pqtype.CIDR")
+	case "macaddr", "macaddr8":
+		return bySQLPackage("net.HardwareAddr", "pgtype.Macaddr", "pqtype.Macaddr")
+	case "interval", "pg_catalog.interval":
+		if pgxV5 {
+			return "pgtype.Interval"
+		}
+		return nullable("int64", "", "sql.NullInt64")
+	case "daterange":
+		return bySQLPackage("pgtype.Range[pgtype.Date]", "pgtype.Daterange", "any")
+	case "tsrange":
+		return bySQLPackage("pgtype.Range[pgtype.Timestamp]", "pgtype.Tsrange", "any")
+	case "tstzrange":
+		return bySQLPackage("pgtype.Range[pgtype.Timestamptz]", "pgtype.Tstzrange", "any")
+	case "numrange":
+		return bySQLPackage("pgtype.Range[pgtype.Numeric]", "pgtype.Numrange", "any")
+	case "int4range":
+		return bySQLPackage("pgtype.Range[pgtype.Int4]", "pgtype.Int4range", "any")
+	case "int8range":
+		return bySQLPackage("pgtype.Range[pgtype.Int8]", "pgtype.Int8range", "any")
+	case "datemultirange":
+		return bySQLPackage("pgtype.Multirange[pgtype.Range[pgtype.Date]]", "any", "any")
+	case "tsmultirange":
+		return bySQLPackage("pgtype.Multirange[pgtype.Range[pgtype.Timestamp]]", "any", "any")
+	case "tstzmultirange":
+		return bySQLPackage("pgtype.Multirange[pgtype.Range[pgtype.Timestamptz]]", "any", "any")
+	case "nummultirange":
+		return bySQLPackage("pgtype.Multirange[pgtype.Range[pgtype.Numeric]]", "any", "any")
+	case "int4multirange":
+		return bySQLPackage("pgtype.Multirange[pgtype.Range[pgtype.Int4]]", "any", "any")
+	case "int8multirange":
+		return bySQLPackage("pgtype.Multirange[pgtype.Range[pgtype.Int8]]", "any", "any")
+	case "hstore":
+		return bySQLPackage("pgtype.Hstore", "pgtype.Hstore", "any")
+	case "bit", "varbit", "pg_catalog.bit", "pg_catalog.varbit":
+		return bySQLPackage("pgtype.Bits", "pgtype.Varbit", "any")
+	case "cid":
+		return bySQLPackage("pgtype.Uint32", "pgtype.CID", "any")
+	case "oid":
+		return bySQLPackage("pgtype.Uint32", "pgtype.OID", "any")
+	case "xid":
+		return bySQLPackage("pgtype.Uint32", "pgtype.XID", "any")
+	case "tid":
+		return bySQLPackage("pgtype.TID", "pgtype.TID", "any")
+	case "box", "circle", "line", "lseg", "path", "point", "polygon":
+		if pgx {
+			return "pgtype." + sdk.Title(columnType)
+		}
+		return "any"
+	case "vector":
+		if emitPointersForNull {
+			return bySQLPackage("*pgvector.Vector", "any", "any")
+		}
+		return bySQLPackage("pgvector.Vector", "any", "any")
+	case "void", "any":
+		return "any"
+	}
+
+	// User-defined enums and composite types
+	schemaName, typeName, ok := strings.Cut(columnType, ".")
+	if !ok {
+		schemaName, typeName = req.GetCatalog().GetDefaultSchema(), columnType
+	}
+	for _, schema := range req.GetCatalog().GetSchemas() {
+		if schema.GetName() == "pg_catalog" || schema.GetName() == "information_schema" ||
+			schema.GetName() != schemaName {
+			continue
+		}
+		for _, enum := range schema.GetEnums() {
+			if enum.GetName() != typeName {
+				continue
+			}
+			enumName := enum.GetName()
+			if schema.GetName() != req.GetCatalog().GetDefaultSchema() {
+				enumName = schema.GetName() + "_" + enumName
+			}
+			// sqlc-gen-go does not use pointers for enums
+			if notNull {
+				return structName(enumName, opts)
+			}
+			return "Null" + structName(enumName, opts)
+		}
+		for _, ct := range schema.GetCompositeTypes() {
+			if ct.GetName() == typeName {
+				return nullable("string", "sql.NullString", "sql.NullString")
+			}
+		}
+	}
+	return "any"
+}
+
+func sqliteGoType(opts *Options, col *plugin.Column) string {
+	dt := strings.ToLower(sdk.DataType(col.GetType()))
+	notNull := col.GetNotNull() || col.GetIsArray()
+
+	// nullable returns typ for NOT NULL columns, a pointer to typ with emit_pointers_for_null_types,
+	// and nullType otherwise
+	nullable := func(typ, nullType string) string {
+		switch {
+		case notNull:
+			return typ
+		case opts.EmitPointersForNullTypes:
+			return "*" + typ
+		default:
+			return nullType
+		}
+	}
+
+	switch dt {
+	case "int", "integer", "tinyint", "smallint", "mediumint", "bigint", "unsignedbigint", "int2", "int8":
+		return nullable("int64", "sql.NullInt64")
+	case "blob":
+		return "[]byte"
+	case "real", "double", "doubleprecision", "float":
+		return nullable("float64", "sql.NullFloat64")
+	case "boolean", "bool":
+		return nullable("bool", "sql.NullBool")
+	case "date", "datetime", "timestamp":
+		return nullable("time.Time", "sql.NullTime")
+	case "json", "jsonb":
+		return "json.RawMessage"
+	case "any":
+		return "any"
+	}
+
+	switch {
+	case strings.HasPrefix(dt, "character"),
+		strings.HasPrefix(dt, "varchar"),
+		strings.HasPrefix(dt, "varyingcharacter"),
+		strings.HasPrefix(dt, "nchar"),
+		strings.HasPrefix(dt, "nativecharacter"),
+		strings.HasPrefix(dt, "nvarchar"),
+		dt == "text",
+		dt == "clob":
+		return nullable("string", "sql.NullString")
+	case strings.HasPrefix(dt, "decimal"), dt == "numeric":
+		return nullable("float64", "sql.NullFloat64")
+	}
+	return "any"
+}
+
+// goTypeImports maps the package qualifier of a Go type to its import path.
+var goTypeImports = map[string]string{
+	"sql":      "database/sql",
+	"time":     "time",
+	"json":     "encoding/json",
+	"net":      "net",
+	"netip":    "net/netip",
+	"uuid":     "github.com/google/uuid",
+	"pqtype":   "github.com/sqlc-dev/pqtype",
+	"pq":       "github.com/lib/pq",
+	"pgvector": "github.com/pgvector/pgvector-go",
+}
+
+// goTypeImport returns the import path needed to use a Go type returned by goType, or "" if none is needed.
+// The type of an override is imported from the import path of the override.
+func goTypeImport(typ string, opts *Options) string {
+	typ = strings.TrimLeft(typ, "[]*")
+	for _, override := range opts.Overrides {
+		if !override.goBasicType && strings.TrimLeft(override.goTypeName, "[]*") == typ {
+			return override.goImportPath
+		}
+	}
+	qualifier, _, ok := strings.Cut(typ, ".")
+	if !ok {
+		return ""
+	}
+	if qualifier == "pgtype" {
+		// pgx/v4 uses the standalone pgtype module
+		if opts.SqlPackage == sqlPackagePgxV4 {
+			return "github.com/jackc/pgtype"
+		}
+		return "github.com/jackc/pgx/v5/pgtype"
+	}
+	return goTypeImports[qualifier]
+}
+
+// importSpec returns the import spec of an import path, which is named after the package of an override
+// when sqlc-gen-go imports it with the name, e.g., `uuid "github.com/gofrs/uuid/v5"`.
+func importSpec(path string, opts *Options) string {
+	for _, override := range opts.Overrides {
+		if override.goImportPath == path && override.goPackage != "" {
+			return override.goPackage + " " + strconv.Quote(path)
+		}
+	}
+	return strconv.Quote(path)
+}
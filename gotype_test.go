@@ -0,0 +1,274 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/sqlc-dev/plugin-sdk-go/plugin"
+	"gotest.tools/v3/assert"
+)
+
+func Test_goType(t *testing.T) {
+	t.Parallel()
+	catalog := &plugin.Catalog{
+		DefaultSchema: "public",
+		Schemas: []*plugin.Schema{
+			{Name: "public", Enums: []*plugin.Enum{{Name: "user_status"}}},
+			{Name: "audit", Enums: []*plugin.Enum{{Name: "action"}}},
+		},
+	}
+	tests := map[string]struct {
+		engine     string
+		sqlPackage string
+		// options are plugin options other than package
+		options string
+		col     *plugin.Column
+		want    string
+	}{
+		"postgresql: not null bigint": {
+			engine: "postgresql",
+			col:    &plugin.Column{NotNull: true, Type: &plugin.Identifier{Name: "int8"}},
+			want:   "int64",
+		},
+		"postgresql: nullable text": {
+			engine: "postgresql",
+			col:    &plugin.Column{Type: &plugin.Identifier{Name: "text"}},
+			want:   "sql.NullString",
+		},
+		"postgresql: schema-qualified type": {
+			engine: "postgresql",
+			col:    &plugin.Column{NotNull: true, Type: &plugin.Identifier{Schema: "pg_catalog", Name: "int4"}},
+			want:   "int32",
+		},
+		"postgresql: uuid array": {
+			engine: "postgresql",
+			col:    &plugin.Column{IsArray: true, ArrayDims: 1, Type: &plugin.Identifier{Name: "uuid"}},
+			want:   "[]uuid.UUID",
+		},
+		"postgresql: enum": {
+			engine: "postgresql",
+			col:    &plugin.Column{NotNull: true, Type: &plugin.Identifier{Name: "user_status"}},
+			want:   "UserStatus",
+		},
+		"postgresql: nullable enum in another schema": {
+			engine: "postgresql",
+			col:    &plugin.Column{Type: &plugin.Identifier{Schema: "audit", Name: "action"}},
+			want:   "NullAuditAction",
+		},
+		"postgresql: unknown type": {
+			engine: "postgresql",
+			col:    &plugin.Column{NotNull: true, Type: &plugin.Identifier{Name: "tsvector"}},
+			want:   "any",
+		},
+		"postgresql: pgx/v5 nullable text": {
+			engine:     "postgresql",
+			sqlPackage: "pgx/v5",
+			col:        &plugin.Column{Type: &plugin.Identifier{Name: "text"}},
+			want:       "pgtype.Text",
+		},
+		"postgresql: pgx/v5 not null timestamptz": {
+			engine:     "postgresql",
+			sqlPackage: "pgx/v5",
+			col:        &plugin.Column{NotNull: true, Type: &plugin.Identifier{Name: "timestamptz"}},
+			want:       "pgtype.Timestamptz",
+		},
+		"postgresql: pgx/v5 nullable inet": {
+			engine:     "postgresql",
+			sqlPackage: "pgx/v5",
+			col:        &plugin.Column{Type: &plugin.Identifier{Name: "inet"}},
+			want:       "*netip.Addr",
+		},
+		"postgresql: pgx/v5 nullable enum": {
+			engine:     "postgresql",
+			sqlPackage: "pgx/v5",
+			col:        &plugin.Column{Type: &plugin.Identifier{Name: "user_status"}},
+			want:       "NullUserStatus",
+		},
+		"postgresql: pgx/v4 nullable text": {
+			engine:     "postgresql",
+			sqlPackage: "pgx/v4",
+			col:        &plugin.Column{Type: &plugin.Identifier{Name: "text"}},
+			want:       "sql.NullString",
+		},
+		"postgresql: pgx/v4 jsonb": {
+			engine:     "postgresql",
+			sqlPackage: "pgx/v4",
+			col:        &plugin.Column{NotNull: true, Type: &plugin.Identifier{Name: "jsonb"}},
+			want:       "pgtype.JSONB",
+		},
+		"postgresql: pgx/v4 point": {
+			engine:     "postgresql",
+			sqlPackage: "pgx/v4",
+			col:        &plugin.Column{NotNull: true, Type: &plugin.Identifier{Name: "point"}},
+			want:       "pgtype.Point",
+		},
+		"postgresql: pgx/v5 nullable int4 with emit_pointers_for_null_types": {
+			engine:     "postgresql",
+			sqlPackage: "pgx/v5",
+			options:    `"emit_pointers_for_null_types": true`,
+			col:        &plugin.Column{Type: &plugin.Identifier{Name: "int4"}},
+			want:       "*int32",
+		},
+		"postgresql: pgx/v4 nullable timestamptz with emit_pointers_for_null_types": {
+			engine:     "postgresql",
+			sqlPackage: "pgx/v4",
+			options:    `"emit_pointers_for_null_types": true`,
+			col:        &plugin.Column{Type: &plugin.Identifier{Name: "timestamptz"}},
+			want:       "*time.Time",
+		},
+		"postgresql: pgx/v5 nullable enum with emit_pointers_for_null_types": {
+			engine:     "postgresql",
+			sqlPackage: "pgx/v5",
+			options:    `"emit_pointers_for_null_types": true`,
+			col:        &plugin.Column{Type: &plugin.Identifier{Name: "user_status"}},
+			want:       "NullUserStatus",
+		},
+		"postgresql: database/sql nullable text with emit_pointers_for_null_types": {
+			engine:  "postgresql",
+			options: `"emit_pointers_for_null_types": true`,
+			col:     &plugin.Column{Type: &plugin.Identifier{Name: "text"}},
+			want:    "sql.NullString",
+		},
+		"postgresql: db_type override": {
+			engine:     "postgresql",
+			sqlPackage: "pgx/v5",
+			options:    `"overrides": [{"db_type": "uuid", "go_type": "github.com/google/uuid.UUID"}]`,
+			col:        &plugin.Column{NotNull: true, Type: &plugin.Identifier{Name: "uuid"}},
+			want:       "uuid.UUID",
+		},
+		"postgresql: db_type override of a nullable column": {
+			engine:     "postgresql",
+			sqlPackage: "pgx/v5",
+			options:    `"overrides": [{"db_type": "uuid", "go_type": "github.com/google/uuid.UUID"}]`,
+			col:        &plugin.Column{Type: &plugin.Identifier{Name: "uuid"}},
+			want:       "pgtype.UUID",
+		},
+		"postgresql: nullable db_type override": {
+			engine:     "postgresql",
+			sqlPackage: "pgx/v5",
+			options: `"overrides": [{"db_type": "uuid", "nullable": true,
+				"go_type": {"import": "github.com/gofrs/uuid/v5", "type": "NullUUID"}}]`,
+			col:  &plugin.Column{Type: &plugin.Identifier{Name: "uuid"}},
+			want: "uuid.NullUUID",
+		},
+		"postgresql: column override": {
+			engine:  "postgresql",
+			options: `"overrides": [{"column": "users.tags", "go_type": "github.com/lib/pq.StringArray"}]`,
+			col: &plugin.Column{
+				Name: "tags", NotNull: true, IsArray: true, ArrayDims: 1,
+				Table: &plugin.Identifier{Name: "users"}, Type: &plugin.Identifier{Name: "text"},
+			},
+			want: "pq.StringArray",
+		},
+		"postgresql: column override of another table": {
+			engine:  "postgresql",
+			options: `"overrides": [{"column": "users.tags", "go_type": "github.com/lib/pq.StringArray"}]`,
+			col: &plugin.Column{
+				Name: "tags", NotNull: true, IsArray: true, ArrayDims: 1,
+				Table: &plugin.Identifier{Name: "posts"}, Type: &plugin.Identifier{Name: "text"},
+			},
+			want: "[]string",
+		},
+		"mysql: unsigned bigint": {
+			engine: "mysql",
+			col:    &plugin.Column{NotNull: true, Unsigned: true, Type: &plugin.Identifier{Name: "bigint"}},
+			want:   "uint64",
+		},
+		"mysql: tinyint(1)": {
+			engine: "mysql",
+			col:    &plugin.Column{NotNull: true, Length: 1, Type: &plugin.Identifier{Name: "tinyint"}},
+			want:   "bool",
+		},
+		"mysql: nullable datetime": {
+			engine: "mysql",
+			col:    &plugin.Column{Type: &plugin.Identifier{Name: "datetime"}},
+			want:   "sql.NullTime",
+		},
+		"sqlite: varchar(255)": {
+			engine: "sqlite",
+			col:    &plugin.Column{NotNull: true, Type: &plugin.Identifier{Name: "VARCHAR(255)"}},
+			want:   "string",
+		},
+		"sqlite: nullable integer with emit_pointers_for_null_types": {
+			engine:  "sqlite",
+			options: `"emit_pointers_for_null_types": true`,
+			col:     &plugin.Column{Type: &plugin.Identifier{Name: "integer"}},
+			want:    "*int64",
+		},
+		"sqlc.slice": {
+			engine: "mysql",
+			col:    &plugin.Column{NotNull: true, IsSqlcSlice: true, Type: &plugin.Identifier{Name: "int"}},
+			want:   "[]int32",
+		},
+		"unknown engine": {
+			engine: "",
+			col:    &plugin.Column{NotNull: true, Type: &plugin.Identifier{Name: "text"}},
+			want:   "any",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			req := &plugin.GenerateRequest{Settings: &plugin.Settings{Engine: tt.engine}, Catalog: catalog}
+			pluginOptions := `{"package": "db"}`
+			if tt.options != "" {
+				pluginOptions = `{"package": "db", ` + tt.options + `}`
+			}
+			opts := newTestOptions(t, pluginOptions)
+			opts.SqlPackage = tt.sqlPackage
+			assert.Equal(t, goType(req, opts, tt.col), tt.want)
+		})
+	}
+}
+
+func Test_goTypeImport(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		typ        string
+		sqlPackage string
+		// options are plugin options other than package
+		options string
+		want    string
+	}{
+		"builtin":         {typ: "int64", want: ""},
+		"database/sql":    {typ: "sql.NullString", want: "database/sql"},
+		"pointer":         {typ: "*time.Time", want: "time"},
+		"slice":           {typ: "[]uuid.UUID", want: "github.com/google/uuid"},
+		"generated enum":  {typ: "NullUserStatus", want: ""},
+		"unknown package": {typ: "decimal.Decimal", want: ""},
+		"pgx/v5 pgtype":   {typ: "pgtype.Range[pgtype.Date]", sqlPackage: "pgx/v5", want: "github.com/jackc/pgx/v5/pgtype"},
+		"pgx/v4 pgtype":   {typ: "pgtype.JSONB", sqlPackage: "pgx/v4", want: "github.com/jackc/pgtype"},
+		"override": {
+			typ:     "uuid.UUID",
+			options: `"overrides": [{"db_type": "uuid", "go_type": "github.com/gofrs/uuid.UUID"}]`,
+			want:    "github.com/gofrs/uuid",
+		},
+		"override with a package name": {
+			typ:     "*uuid.UUID",
+			options: `"overrides": [{"db_type": "uuid", "go_type": {"import": "github.com/gofrs/uuid/v5", "type": "UUID", "pointer": true}}]`,
+			want:    "github.com/gofrs/uuid/v5",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			pluginOptions := `{"package": "db"}`
+			if tt.options != "" {
+				pluginOptions = `{"package": "db", ` + tt.options + `}`
+			}
+			opts := newTestOptions(t, pluginOptions)
+			opts.SqlPackage = tt.sqlPackage
+			assert.Equal(t, goTypeImport(tt.typ, opts), tt.want)
+		})
+	}
+}
+
+func Test_importSpec(t *testing.T) {
+	t.Parallel()
+	opts := newTestOptions(t, `{"package": "db", "overrides": [
+		{"db_type": "uuid", "go_type": {"import": "github.com/gofrs/uuid/v5", "type": "UUID"}},
+		{"db_type": "money", "go_type": "github.com/shopspring/decimal.Decimal"}
+	]}`)
+	assert.Equal(t, importSpec("github.com/gofrs/uuid/v5", opts), `uuid "github.com/gofrs/uuid/v5"`)
+	assert.Equal(t, importSpec("github.com/shopspring/decimal", opts), `"github.com/shopspring/decimal"`)
+	assert.Equal(t, importSpec("time", opts), `"time"`)
+}
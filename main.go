@@ -4,6 +4,8 @@ import (
 	"context"
 	"embed"
 	"fmt"
+	"slices"
+	"strings"
 
 	"github.com/sqlc-dev/plugin-sdk-go/codegen"
 	"github.com/sqlc-dev/plugin-sdk-go/plugin"
@@ -20,6 +22,53 @@ const (
 	sourceTemplateFunc2    = "buildBulkInsertQuery"
 )
 
+// sourceTemplateHelpers are the declarations in sourceTemplateFuncPath
+// that sourceTemplateFunc1 and sourceTemplateFunc2 depend on.
+var sourceTemplateHelpers = []string{
+	"buildOrderedBulkInsertQuery",
+	"bulkOrderedChunkSize",
+	"parseBulkInsertQuery",
+	"bulkChunkSize",
+	"bulkChunkEnds",
+	"bulkEstimateValueSize",
+	"bulkInsertQuery",
+	"bulkParseInsertQuery",
+	"bulkSQLTokenWord",
+	"bulkSQLToken",
+	"bulkTokenizeSQL",
+}
+
+// seqTemplateHelpers are the declarations in sourceTemplateFuncPath
+// that the BulkXxxSeq functions depend on. They are copied only when the functions are generated.
+var seqTemplateHelpers = []string{
+	"bulkInsertSeq",
+	"bulkSeq2",
+}
+
+// bulkWriterTemplateHelpers are the declarations in sourceTemplateFuncPath
+// that the XxxBulkWriter types depend on. They are copied only when the types are generated.
+var bulkWriterTemplateHelpers = []string{
+	"bulkWriter",
+	"newBulkWriter",
+}
+
+// asyncBulkWriterTemplateHelpers are the declarations in sourceTemplateFuncPath
+// that the XxxAsyncBulkWriter types depend on. They are copied only when the types are generated.
+var asyncBulkWriterTemplateHelpers = []string{
+	"bulkAsyncWriter",
+	"newBulkAsyncWriter",
+	"bulkTicker",
+}
+
+// loadDataTemplateHelpers are the declarations in sourceTemplateFuncPath
+// that the BulkXxxLoadData functions depend on. They are copied only when the functions are generated.
+var loadDataTemplateHelpers = []string{
+	"bulkLoadDataReaderID",
+	"bulkLoadDataLocalInfile",
+	"bulkWriteLoadDataRows",
+	"bulkAppendLoadDataValue",
+}
+
 func main() {
 	codegen.Run(Generate)
 }
@@ -32,8 +81,20 @@ func Generate(ctx context.Context, req *plugin.GenerateRequest) (*plugin.Generat
 	if err := ValidateOptions(opts); err != nil {
 		return nil, err
 	}
+	if opts.PreserveReturningOrder && req.GetSettings().GetEngine() != "postgresql" {
+		return nil, fmt.Errorf(`options: "preserve_returning_order" is supported only by the postgresql engine`)
+	}
+	if opts.BulkMode == bulkModeUnnest && req.GetSettings().GetEngine() != "postgresql" {
+		return nil, fmt.Errorf(`options: "bulk_mode" %q is supported only by the postgresql engine`, opts.BulkMode)
+	}
+	if opts.EmitLoadDataFunctions && req.GetSettings().GetEngine() != "mysql" {
+		return nil, fmt.Errorf(`options: "emit_load_data_functions" is supported only by the mysql engine`)
+	}
 
-	bulkInserts := buildBulkInsert(req, opts)
+	bulkInserts, err := buildBulkInsert(req, opts)
+	if err != nil {
+		return nil, err
+	}
 
 	if len(bulkInserts) == 0 {
 		// Returns an empty response if nothing is generated
@@ -44,6 +105,98 @@ func Generate(ctx context.Context, req *plugin.GenerateRequest) (*plugin.Generat
 	return generate(ctx, req, opts, bulkInserts)
 }
 
+// generateImports returns the import specs of the standard library and third-party packages of the generated file.
+func generateImports(structs BulkInserts, opts *Options) ([]string, []string) {
+	// Packages used by the helper functions and the bulk functions
+	std := []string{"context", "database/sql/driver", "errors", "fmt", "reflect", "slices", "strconv", "strings"}
+	var pkg []string
+	// Packages used by the transaction helper
+	switch opts.SqlPackage {
+	case sqlPackagePgxV4:
+		pkg = append(pkg, "github.com/jackc/pgx/v4")
+	case sqlPackagePgxV5:
+		pkg = append(pkg, "github.com/jackc/pgx/v5")
+	default:
+		std = append(std, "database/sql")
+	}
+	// Package of the command tags of BulkResult
+	if opts.usesPgx() && structs.hasCmd(":execresult") {
+		if opts.SqlPackage == sqlPackagePgxV4 {
+			pkg = append(pkg, "github.com/jackc/pgconn")
+		} else {
+			pkg = append(pkg, "github.com/jackc/pgx/v5/pgconn")
+		}
+	}
+	// Package used by the BulkXxxSeq functions
+	if structs.hasSeqFunctions() {
+		std = append(std, "iter")
+	}
+	// Packages used by the async bulk writers
+	if structs.hasAsyncBulkWriters() {
+		std = append(std, "sync", "time")
+	}
+	// Packages used by the LOAD DATA helpers
+	if structs.hasLoadDataFunctions() {
+		std = append(std, "io", "sync/atomic", "time")
+		pkg = append(pkg, "github.com/go-sql-driver/mysql")
+	}
+	for _, s := range structs {
+		types := []string{s.RowType, s.ReturnType}
+		if slices.ContainsFunc(s.ScanDests, func(dest string) bool { return strings.HasPrefix(dest, "pq.Array(") }) ||
+			(s.UnnestQuery != "" && !opts.usesPgx()) {
+			types = append(types, "pq.Array")
+		}
+		for _, f := range s.RowFields {
+			types = append(types, f.Type)
+		}
+		for _, p := range s.StatementParams {
+			types = append(types, p.Type)
+		}
+		// The element types of the arrays of bulk_mode "unnest"
+		for _, c := range s.UnnestColumns {
+			types = append(types, c.Type)
+		}
+		for _, typ := range types {
+			path := goTypeImport(typ, opts)
+			switch {
+			case path == "" || slices.Contains(std, path) || slices.Contains(pkg, path):
+			case strings.Contains(path, "."):
+				pkg = append(pkg, path)
+			default:
+				std = append(std, path)
+			}
+		}
+	}
+	// The optional functions may share packages, e.g., "time"
+	slices.Sort(std)
+	slices.Sort(pkg)
+	std, pkg = slices.Compact(std), slices.Compact(pkg)
+	for i, path := range std {
+		std[i] = importSpec(path, opts)
+	}
+	for i, path := range pkg {
+		pkg[i] = importSpec(path, opts)
+	}
+	return std, pkg
+}
+
+// usesNumberedPlaceholders reports whether the engine binds parameters with numbered placeholders ($1, $2, ...).
+// MySQL and SQLite use "?" placeholders.
+func usesNumberedPlaceholders(engine string) bool {
+	return engine == "postgresql"
+}
+
+// maxPlaceholders returns the maximum number of parameters that a statement can bind on the engine.
+// PostgreSQL and MySQL accept 65535 parameters; SQLite accepts 32766 by default (SQLITE_MAX_VARIABLE_NUMBER).
+func maxPlaceholders(engine string) int {
+	switch engine {
+	case "postgresql", "mysql":
+		return 65535
+	default:
+		return 32766
+	}
+}
+
 func generate(
 	ctx context.Context, req *plugin.GenerateRequest, opts *Options, structs BulkInserts,
 ) (*plugin.GenerateResponse, error) {
@@ -55,23 +208,74 @@ func generate(
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse function %s: %w", sourceTemplateFunc2, err)
 	}
+	helperNames := sourceTemplateHelpers
+	if structs.hasSeqFunctions() {
+		helperNames = slices.Concat(helperNames, seqTemplateHelpers)
+	}
+	if structs.hasBulkWriters() {
+		helperNames = slices.Concat(helperNames, bulkWriterTemplateHelpers)
+	}
+	if structs.hasAsyncBulkWriters() {
+		helperNames = slices.Concat(helperNames, asyncBulkWriterTemplateHelpers)
+	}
+	if structs.hasLoadDataFunctions() {
+		helperNames = slices.Concat(helperNames, loadDataTemplateHelpers)
+	}
+	helperFns := make([]string, 0, len(helperNames))
+	for _, name := range helperNames {
+		helperFn, err := parseGoCode(sourceTemplateFuncPath, name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse declaration %s: %w", name, err)
+		}
+		helperFns = append(helperFns, string(helperFn))
+	}
+
+	stdImports, pkgImports := generateImports(structs, opts)
 
 	tmpl := struct {
-		Package       string
-		SqlcVersion   string
-		BulkInsert    []BulkInsert
-		ExtractFnName string
-		ExtractFn     string
-		BuildFnName   string
-		BuildFn       string
+		Package              string
+		SqlcVersion          string
+		StdImports           []string
+		PkgImports           []string
+		NumberedPlaceholders bool
+		EmitMethodsWithDB    bool
+		UsesPgx              bool
+		MaxPlaceholders      int
+		BatchSize            int
+		MaxBatchBytes        int
+		EmitBulkResult       bool
+		EmitCopier           bool
+		EmitBatchSender      bool
+		CheckAutoIncrement   bool
+		EmitAutoIncrementFn  bool
+		BulkInsert           []BulkInsert
+		ExtractFnName        string
+		ExtractFn            string
+		BuildFnName          string
+		BuildFn              string
+		HelperFns            string
 	}{
-		Package:       opts.Package,
-		SqlcVersion:   req.GetSqlcVersion(),
-		BulkInsert:    structs,
-		ExtractFnName: sourceTemplateFunc1,
-		ExtractFn:     string(extractFieldValuesFn),
-		BuildFnName:   sourceTemplateFunc2,
-		BuildFn:       string(buildBulkInsertQueryFn),
+		Package:              opts.Package,
+		SqlcVersion:          req.GetSqlcVersion(),
+		StdImports:           stdImports,
+		PkgImports:           pkgImports,
+		NumberedPlaceholders: usesNumberedPlaceholders(req.GetSettings().GetEngine()),
+		EmitMethodsWithDB:    opts.EmitMethodsWithDBArgument,
+		UsesPgx:              opts.usesPgx(),
+		MaxPlaceholders:      maxPlaceholders(req.GetSettings().GetEngine()),
+		BatchSize:            opts.BatchSize,
+		MaxBatchBytes:        opts.MaxBatchBytes,
+		EmitBulkResult:       structs.hasCmd(":execresult"),
+		EmitCopier:           structs.hasCopyFunctions(),
+		EmitBatchSender:      structs.hasBatchFunctions(),
+		CheckAutoIncrement:   !opts.SkipAutoIncrementCheck,
+		EmitAutoIncrementFn:  !opts.SkipAutoIncrementCheck && structs.hasLastInsertIDs(),
+		BulkInsert:           structs,
+		ExtractFnName:        sourceTemplateFunc1,
+		ExtractFn:            string(extractFieldValuesFn),
+		BuildFnName:          sourceTemplateFunc2,
+		BuildFn:              string(buildBulkInsertQueryFn),
+		HelperFns:            strings.Join(helperFns, "\n\n"),
 	}
 
 	code, err := executeTemplate(ctx, "bulkInsertFile", tmpl)
@@ -23,7 +23,12 @@ func TestGenerate(t *testing.T) {
 	}
 	type Expected struct {
 		fileCount int
-		err       error
+		contains  []string
+		// notContains are the snippets that the generated code must not contain
+		notContains []string
+		// mockBaseGo replaces defaultMockBaseGo for type checking the generated code
+		mockBaseGo string
+		err        error
 	}
 
 	tests := map[string]struct {
@@ -39,8 +44,35 @@ func TestGenerate(t *testing.T) {
 							Name: "InsertUser",
 							Text: "INSERT INTO users (id, name) VALUES (?, ?)",
 							Params: []*plugin.Parameter{
-								{Column: &plugin.Column{Name: "id"}},
-								{Column: &plugin.Column{Name: "name"}},
+								{Number: 1, Column: &plugin.Column{Name: "id"}},
+								{Number: 2, Column: &plugin.Column{Name: "name"}},
+							},
+						},
+					},
+				}
+				return Args{req: req}, Expected{
+					fileCount: 1,
+					contains: []string{
+						"func (q *Queries) BulkInsertUser(ctx context.Context, args BulkInsertUserParams) error {",
+						"func (q *Queries) BulkInsertUserTx(ctx context.Context, args BulkInsertUserParams) error {",
+						"if tx, ok := db.(*sql.Tx); ok {",
+					},
+					err: nil,
+				}
+			},
+		},
+		"valid:Extra suffix INSERT Query": {
+			arrange: func(t *testing.T) (Args, Expected) {
+				req := &plugin.GenerateRequest{
+					SqlcVersion:   "1.0.0",
+					PluginOptions: []byte(`{"package": "sqlc"}`),
+					Queries: []*plugin.Query{
+						{
+							Name: "InsertUser",
+							Text: "INSERT INTO users (id, name) VALUES (?, ?) ON DUPLICATE KEY UPDATE id = id",
+							Params: []*plugin.Parameter{
+								{Number: 1, Column: &plugin.Column{Name: "id"}},
+								{Number: 2, Column: &plugin.Column{Name: "name"}},
 							},
 						},
 					},
@@ -48,23 +80,2240 @@ func TestGenerate(t *testing.T) {
 				return Args{req: req}, Expected{fileCount: 1, err: nil}
 			},
 		},
-		"valid:Extra suffix INSERT Query": {
+		"valid:PostgreSQL numbered placeholders": {
+			arrange: func(t *testing.T) (Args, Expected) {
+				req := &plugin.GenerateRequest{
+					SqlcVersion:   "1.0.0",
+					Settings:      &plugin.Settings{Engine: "postgresql"},
+					PluginOptions: []byte(`{"package": "sqlc"}`),
+					Queries: []*plugin.Query{
+						{
+							Name: "InsertUser",
+							Text: "INSERT INTO users (id, name) VALUES ($1, $2)",
+							Params: []*plugin.Parameter{
+								{Number: 1, Column: &plugin.Column{Name: "id"}},
+								{Number: 2, Column: &plugin.Column{Name: "name"}},
+							},
+						},
+					},
+				}
+				return Args{req: req}, Expected{
+					fileCount: 1,
+					contains:  []string{"originalQuery, end-start, numParamsPerArg, 0, true,"},
+					err:       nil,
+				}
+			},
+		},
+		"valid:Statement-level parameters": {
+			arrange: func(t *testing.T) (Args, Expected) {
+				req := &plugin.GenerateRequest{
+					SqlcVersion:   "1.0.0",
+					Settings:      &plugin.Settings{Engine: "postgresql"},
+					PluginOptions: []byte(`{"package": "sqlc"}`),
+					Queries: []*plugin.Query{
+						{
+							Name: "UpsertUser",
+							Text: "INSERT INTO users (id, name) VALUES ($1, $2) " +
+								"ON CONFLICT (id) DO UPDATE SET updated_by = $3, updated_at = $4",
+							Params: []*plugin.Parameter{
+								{Number: 1, Column: &plugin.Column{Name: "id", NotNull: true, Type: &plugin.Identifier{Name: "int8"}}},
+								{Number: 2, Column: &plugin.Column{Name: "name", NotNull: true, Type: &plugin.Identifier{Name: "text"}}},
+								{Number: 3, Column: &plugin.Column{Name: "updated_by", NotNull: true, Type: &plugin.Identifier{Name: "text"}}},
+								{Number: 4, Column: &plugin.Column{Name: "updated_at", Type: &plugin.Identifier{Name: "timestamptz"}}},
+							},
+						},
+					},
+				}
+				const mockBaseGo = `
+package sqlc
+
+import (
+	"context"
+	"database/sql"
+)
+
+type DBTX interface {
+	ExecContext(context.Context, string, ...any) (sql.Result, error)
+}
+
+type Queries struct {
+	db DBTX
+}
+
+const upsertUser = "INSERT INTO users (id, name) VALUES ($1, $2) ON CONFLICT (id) DO UPDATE SET updated_by = $3, updated_at = $4"
+
+type UpsertUserParams struct {
+	ID        int64
+	Name      string
+	UpdatedBy string
+	UpdatedAt sql.NullTime
+}
+`
+				return Args{req: req}, Expected{
+					fileCount: 1,
+					contains: []string{
+						"args BulkUpsertUserParams, stmtUpdatedBy string, stmtUpdatedAt sql.NullTime) error {",
+						"paramFieldNamesForQuery := []string{\"ID\", \"Name\"}",
+						"chunkSize, err := bulkChunkSize(numParamsPerArg, 2, 65535, 0)",
+						"originalQuery, end-start, numParamsPerArg, 2, true,",
+						"statementValues := []any{stmtUpdatedBy, stmtUpdatedAt}",
+						"chunkValues = slices.Concat(chunkValues, statementValues)",
+					},
+					mockBaseGo: mockBaseGo,
+					err:        nil,
+				}
+			},
+		},
+		"valid:sqlc.arg and expression parameters": {
+			arrange: func(t *testing.T) (Args, Expected) {
+				req := &plugin.GenerateRequest{
+					SqlcVersion:   "1.0.0",
+					Settings:      &plugin.Settings{Engine: "mysql"},
+					PluginOptions: []byte(`{"package": "sqlc"}`),
+					Queries: []*plugin.Query{
+						{
+							// INSERT INTO books (author_id, title, slug) VALUES (sqlc.arg(author), ?, LOWER(?))
+							Name: "InsertBook",
+							Text: "INSERT INTO books (author_id, title, slug) VALUES (?, ?, LOWER(?))",
+							Params: []*plugin.Parameter{
+								{Number: 1, Column: &plugin.Column{Name: "author", IsNamedParam: true}},
+								{Number: 2, Column: &plugin.Column{Name: "title"}},
+								{Number: 3, Column: &plugin.Column{}},
+							},
+						},
+					},
+				}
+				const mockBaseGo = `
+package sqlc
+
+import (
+	"context"
+	"database/sql"
+)
+
+type DBTX interface {
+	ExecContext(context.Context, string, ...any) (sql.Result, error)
+}
+
+type Queries struct {
+	db DBTX
+}
+
+const insertBook = "INSERT INTO books (author_id, title, slug) VALUES (?, ?, LOWER(?))"
+
+type InsertBookParams struct {
+	Author  int64
+	Title   string
+	Column3 any
+}
+`
+				return Args{req: req}, Expected{
+					fileCount:  1,
+					contains:   []string{"paramFieldNamesForQuery := []string{\"Author\", \"Title\", \"Column3\"}"},
+					mockBaseGo: mockBaseGo,
+					err:        nil,
+				}
+			},
+		},
+		"valid:MySQL named parameter used more than once": {
+			arrange: func(t *testing.T) (Args, Expected) {
+				req := &plugin.GenerateRequest{
+					SqlcVersion:   "1.0.0",
+					Settings:      &plugin.Settings{Engine: "mysql"},
+					PluginOptions: []byte(`{"package": "sqlc"}`),
+					Queries: []*plugin.Query{
+						{
+							// INSERT INTO tags (name, note) VALUES (sqlc.arg(name), ?) ON DUPLICATE KEY UPDATE name = sqlc.arg(name)
+							Name: "UpsertTag",
+							Text: "INSERT INTO tags (name, note) VALUES (?, ?) ON DUPLICATE KEY UPDATE name = ?",
+							Params: []*plugin.Parameter{
+								{Number: 1, Column: &plugin.Column{Name: "name", IsNamedParam: true, NotNull: true, Type: &plugin.Identifier{Name: "varchar"}}},
+								{Number: 2, Column: &plugin.Column{Name: "note", NotNull: true, Type: &plugin.Identifier{Name: "varchar"}}},
+								{Number: 3, Column: &plugin.Column{Name: "name", IsNamedParam: true, NotNull: true, Type: &plugin.Identifier{Name: "varchar"}}},
+							},
+						},
+					},
+				}
+				const mockBaseGo = `
+package sqlc
+
+import (
+	"context"
+	"database/sql"
+)
+
+type DBTX interface {
+	ExecContext(context.Context, string, ...any) (sql.Result, error)
+}
+
+type Queries struct {
+	db DBTX
+}
+
+const upsertTag = "INSERT INTO tags (name, note) VALUES (?, ?) ON DUPLICATE KEY UPDATE name = ?"
+
+type UpsertTagParams struct {
+	Name string
+	Note string
+}
+`
+				return Args{req: req}, Expected{
+					fileCount: 1,
+					contains: []string{
+						"paramFieldNamesForQuery := []string{\"Name\", \"Note\"}",
+						"func (q *Queries) BulkUpsertTag(ctx context.Context, args BulkUpsertTagParams, stmtName string) error {",
+					},
+					mockBaseGo: mockBaseGo,
+					err:        nil,
+				}
+			},
+		},
+		"valid:MySQL named parameter used more than once without a Params struct": {
+			arrange: func(t *testing.T) (Args, Expected) {
+				req := &plugin.GenerateRequest{
+					SqlcVersion:   "1.0.0",
+					Settings:      &plugin.Settings{Engine: "mysql"},
+					PluginOptions: []byte(`{"package": "sqlc", "query_parameter_limit": 3}`),
+					Queries: []*plugin.Query{
+						{
+							Name: "UpsertTag",
+							Text: "INSERT INTO tags (name, note) VALUES (?, ?) ON DUPLICATE KEY UPDATE name = ?",
+							Params: []*plugin.Parameter{
+								{Number: 1, Column: &plugin.Column{Name: "name", IsNamedParam: true, NotNull: true, Type: &plugin.Identifier{Name: "varchar"}}},
+								{Number: 2, Column: &plugin.Column{Name: "note", NotNull: true, Type: &plugin.Identifier{Name: "varchar"}}},
+								{Number: 3, Column: &plugin.Column{Name: "name", IsNamedParam: true, NotNull: true, Type: &plugin.Identifier{Name: "varchar"}}},
+							},
+						},
+					},
+				}
+				const mockBaseGo = `
+package sqlc
+
+import (
+	"context"
+	"database/sql"
+)
+
+type DBTX interface {
+	ExecContext(context.Context, string, ...any) (sql.Result, error)
+}
+
+type Queries struct {
+	db DBTX
+}
+
+const upsertTag = "INSERT INTO tags (name, note) VALUES (?, ?) ON DUPLICATE KEY UPDATE name = ?"
+`
+				return Args{req: req}, Expected{
+					fileCount:  1,
+					contains:   []string{"type BulkUpsertTagRow struct {\n\tName string\n\tNote string\n}"},
+					mockBaseGo: mockBaseGo,
+					err:        nil,
+				}
+			},
+		},
+		"valid:query named like a copied helper": {
+			arrange: func(t *testing.T) (Args, Expected) {
+				req := &plugin.GenerateRequest{
+					SqlcVersion:   "1.0.0",
+					Settings:      &plugin.Settings{Engine: "postgresql"},
+					PluginOptions: []byte(`{"package": "sqlc"}`),
+					Queries: []*plugin.Query{
+						{
+							Name: "InsertQuery",
+							Text: "INSERT INTO queries (id, body) VALUES ($1, $2)",
+							Params: []*plugin.Parameter{
+								{Number: 1, Column: &plugin.Column{Name: "id"}},
+								{Number: 2, Column: &plugin.Column{Name: "body"}},
+							},
+						},
+					},
+				}
+				const mockBaseGo = `
+package sqlc
+
+import (
+	"context"
+	"database/sql"
+)
+
+type DBTX interface {
+	ExecContext(context.Context, string, ...any) (sql.Result, error)
+}
+
+type Queries struct {
+	db DBTX
+}
+
+const insertQuery = "INSERT INTO queries (id, body) VALUES ($1, $2)"
+
+type InsertQueryParams struct {
+	ID   any
+	Body any
+}
+`
+				return Args{req: req}, Expected{
+					fileCount:  1,
+					contains:   []string{"originalQuery := insertQuery", "type bulkInsertQuery struct {"},
+					mockBaseGo: mockBaseGo,
+					err:        nil,
+				}
+			},
+		},
+		"valid:Single parameter": {
+			arrange: func(t *testing.T) (Args, Expected) {
+				req := &plugin.GenerateRequest{
+					SqlcVersion:   "1.0.0",
+					Settings:      &plugin.Settings{Engine: "mysql"},
+					PluginOptions: []byte(`{"package": "sqlc"}`),
+					Queries: []*plugin.Query{
+						{
+							Name: "InsertTag",
+							Text: "INSERT INTO tags (name, created_at) VALUES (?, NOW())",
+							Params: []*plugin.Parameter{
+								{Number: 1, Column: &plugin.Column{Name: "name", Type: &plugin.Identifier{Name: "varchar"}}},
+							},
+						},
+					},
+				}
+				const mockBaseGo = `
+package sqlc
+
+import (
+	"context"
+	"database/sql"
+)
+
+type DBTX interface {
+	ExecContext(context.Context, string, ...any) (sql.Result, error)
+}
+
+type Queries struct {
+	db DBTX
+}
+
+const insertTag = "INSERT INTO tags (name, created_at) VALUES (?, NOW())"
+`
+				return Args{req: req}, Expected{
+					fileCount: 1,
+					contains: []string{
+						"type BulkInsertTagParams []sql.NullString",
+						"numParamsPerArg := 1",
+						"chunkSize, err := bulkChunkSize(numParamsPerArg, 0, 65535, 0)",
+					},
+					mockBaseGo: mockBaseGo,
+					err:        nil,
+				}
+			},
+		},
+		"valid:Single parameter with query_parameter_limit 0": {
+			arrange: func(t *testing.T) (Args, Expected) {
+				req := &plugin.GenerateRequest{
+					SqlcVersion:   "1.0.0",
+					Settings:      &plugin.Settings{Engine: "mysql"},
+					PluginOptions: []byte(`{"package": "sqlc", "query_parameter_limit": 0}`),
+					Queries: []*plugin.Query{
+						{
+							Name: "InsertTag",
+							Text: "INSERT INTO tags (name) VALUES (?)",
+							Params: []*plugin.Parameter{
+								{Number: 1, Column: &plugin.Column{Name: "name", NotNull: true, Type: &plugin.Identifier{Name: "varchar"}}},
+							},
+						},
+					},
+				}
+				const mockBaseGo = `
+package sqlc
+
+import (
+	"context"
+	"database/sql"
+)
+
+type DBTX interface {
+	ExecContext(context.Context, string, ...any) (sql.Result, error)
+}
+
+type Queries struct {
+	db DBTX
+}
+
+const insertTag = "INSERT INTO tags (name) VALUES (?)"
+
+type InsertTagParams struct {
+	Name string
+}
+`
+				return Args{req: req}, Expected{
+					fileCount:  1,
+					contains:   []string{"type BulkInsertTagParams []InsertTagParams"},
+					mockBaseGo: mockBaseGo,
+					err:        nil,
+				}
+			},
+		},
+		"valid:Parameters within query_parameter_limit": {
+			arrange: func(t *testing.T) (Args, Expected) {
+				req := &plugin.GenerateRequest{
+					SqlcVersion:   "1.0.0",
+					Settings:      &plugin.Settings{Engine: "postgresql"},
+					PluginOptions: []byte(`{"package": "sqlc", "query_parameter_limit": 3}`),
+					Queries: []*plugin.Query{
+						{
+							Name: "UpsertUser",
+							Text: "INSERT INTO users (id, name) VALUES ($1, $2) ON CONFLICT (id) DO UPDATE SET updated_by = $3",
+							Params: []*plugin.Parameter{
+								{Number: 1, Column: &plugin.Column{Name: "id", NotNull: true, Type: &plugin.Identifier{Name: "int8"}}},
+								{Number: 2, Column: &plugin.Column{Name: "name", Type: &plugin.Identifier{Name: "text"}}},
+								{Number: 3, Column: &plugin.Column{Name: "updated_by", NotNull: true, Type: &plugin.Identifier{Name: "text"}}},
+							},
+						},
+					},
+				}
+				const mockBaseGo = `
+package sqlc
+
+import (
+	"context"
+	"database/sql"
+)
+
+type DBTX interface {
+	ExecContext(context.Context, string, ...any) (sql.Result, error)
+}
+
+type Queries struct {
+	db DBTX
+}
+
+const upsertUser = "INSERT INTO users (id, name) VALUES ($1, $2) ON CONFLICT (id) DO UPDATE SET updated_by = $3"
+`
+				return Args{req: req}, Expected{
+					fileCount: 1,
+					contains: []string{
+						"type BulkUpsertUserRow struct {\n\tID   int64\n\tName sql.NullString\n}",
+						"type BulkUpsertUserParams []BulkUpsertUserRow",
+						"args BulkUpsertUserParams, stmtUpdatedBy string) error {",
+					},
+					mockBaseGo: mockBaseGo,
+					err:        nil,
+				}
+			},
+		},
+		"valid:emit_exported_queries": {
+			arrange: func(t *testing.T) (Args, Expected) {
+				req := &plugin.GenerateRequest{
+					SqlcVersion:   "1.0.0",
+					PluginOptions: []byte(`{"package": "sqlc", "emit_exported_queries": true}`),
+					Queries: []*plugin.Query{
+						{
+							Name: "InsertUser",
+							Text: "INSERT INTO users (id, name) VALUES (?, ?)",
+							Params: []*plugin.Parameter{
+								{Number: 1, Column: &plugin.Column{Name: "id"}},
+								{Number: 2, Column: &plugin.Column{Name: "name"}},
+							},
+						},
+					},
+				}
+				mockBaseGo := strings.Replace(defaultMockBaseGo, "const insertUser =", "const InsertUser =", 1)
+				return Args{req: req}, Expected{
+					fileCount:  1,
+					contains:   []string{"originalQuery := InsertUser\n"},
+					mockBaseGo: mockBaseGo,
+					err:        nil,
+				}
+			},
+		},
+		"valid:emit_methods_with_db_argument": {
+			arrange: func(t *testing.T) (Args, Expected) {
+				req := &plugin.GenerateRequest{
+					SqlcVersion:   "1.0.0",
+					PluginOptions: []byte(`{"package": "sqlc", "emit_methods_with_db_argument": true}`),
+					Queries: []*plugin.Query{
+						{
+							Name: "InsertUser",
+							Text: "INSERT INTO users (id, name) VALUES (?, ?)",
+							Params: []*plugin.Parameter{
+								{Number: 1, Column: &plugin.Column{Name: "id"}},
+								{Number: 2, Column: &plugin.Column{Name: "name"}},
+							},
+						},
+					},
+				}
+				const mockBaseGo = `
+package sqlc
+
+import (
+	"context"
+	"database/sql"
+)
+
+type DBTX interface {
+	ExecContext(context.Context, string, ...any) (sql.Result, error)
+}
+
+type Queries struct {
+}
+
+const insertUser = "INSERT INTO users (id, name) VALUES (?, ?)"
+
+type InsertUserParams struct {
+	ID   any
+	Name any
+}
+`
+				return Args{req: req}, Expected{
+					fileCount: 1,
+					contains: []string{
+						"BulkInsertUser(ctx context.Context, db DBTX, args BulkInsertUserParams) error {",
+						"BulkInsertUserTx(ctx context.Context, db DBTX, args BulkInsertUserParams) error {",
+						"if _, err := db.ExecContext(ctx, bulkSQL, chunkValues...); err != nil {",
+					},
+					mockBaseGo: mockBaseGo,
+					err:        nil,
+				}
+			},
+		},
+		"valid:emit_params_struct_pointers": {
+			arrange: func(t *testing.T) (Args, Expected) {
+				req := &plugin.GenerateRequest{
+					SqlcVersion:   "1.0.0",
+					PluginOptions: []byte(`{"package": "sqlc", "emit_params_struct_pointers": true}`),
+					Queries: []*plugin.Query{
+						{
+							Name: "InsertUser",
+							Text: "INSERT INTO users (id, name) VALUES (?, ?)",
+							Params: []*plugin.Parameter{
+								{Number: 1, Column: &plugin.Column{Name: "id"}},
+								{Number: 2, Column: &plugin.Column{Name: "name"}},
+							},
+						},
+					},
+				}
+				return Args{req: req}, Expected{
+					fileCount: 1,
+					contains: []string{
+						"type BulkInsertUserParams []*InsertUserParams",
+						"return fmt.Errorf(\"args[%d] of InsertUser is nil\", i)",
+					},
+					err: nil,
+				}
+			},
+		},
+		"valid:pgx/v5 sql_package": {
+			arrange: func(t *testing.T) (Args, Expected) {
+				req := &plugin.GenerateRequest{
+					SqlcVersion:   "1.0.0",
+					Settings:      &plugin.Settings{Engine: "postgresql"},
+					PluginOptions: []byte(`{"package": "sqlc", "sql_package": "pgx/v5"}`),
+					Queries: []*plugin.Query{
+						{
+							Name: "UpsertUser",
+							Text: "INSERT INTO users (id, name) VALUES ($1, $2) ON CONFLICT (id) DO UPDATE SET updated_by = $3",
+							Params: []*plugin.Parameter{
+								{Number: 1, Column: &plugin.Column{Name: "id", NotNull: true, Type: &plugin.Identifier{Name: "int8"}}},
+								{Number: 2, Column: &plugin.Column{Name: "name", Type: &plugin.Identifier{Name: "text"}}},
+								{Number: 3, Column: &plugin.Column{Name: "updated_by", Type: &plugin.Identifier{Name: "text"}}},
+							},
+						},
+					},
+				}
+				const mockBaseGo = `
+package sqlc
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type DBTX interface {
+	Exec(context.Context, string, ...interface{}) (pgconn.CommandTag, error)
+}
+
+type Queries struct {
+	db DBTX
+}
+
+const upsertUser = "INSERT INTO users (id, name) VALUES ($1, $2) ON CONFLICT (id) DO UPDATE SET updated_by = $3"
+
+type UpsertUserParams struct {
+	ID        int64
+	Name      pgtype.Text
+	UpdatedBy pgtype.Text
+}
+`
+				return Args{req: req}, Expected{
+					fileCount: 1,
+					contains: []string{
+						"\"github.com/jackc/pgx/v5/pgtype\"",
+						"args BulkUpsertUserParams, stmtUpdatedBy pgtype.Text) error {",
+						"if _, err := db.Exec(ctx, bulkSQL, chunkValues...); err != nil {",
+						"if tx, ok := db.(pgx.Tx); ok {",
+					},
+					mockBaseGo: mockBaseGo,
+					err:        nil,
+				}
+			},
+		},
+		"valid:RETURNING a single column": {
+			arrange: func(t *testing.T) (Args, Expected) {
+				req := &plugin.GenerateRequest{
+					SqlcVersion:   "1.0.0",
+					Settings:      &plugin.Settings{Engine: "postgresql"},
+					PluginOptions: []byte(`{"package": "sqlc"}`),
+					Queries: []*plugin.Query{
+						{
+							Name: "InsertUser",
+							Cmd:  ":one",
+							Text: "INSERT INTO users (id, name) VALUES ($1, $2) RETURNING id",
+							Params: []*plugin.Parameter{
+								{Number: 1, Column: &plugin.Column{Name: "id"}},
+								{Number: 2, Column: &plugin.Column{Name: "name"}},
+							},
+							Columns: []*plugin.Column{
+								{Name: "id", NotNull: true, Type: &plugin.Identifier{Name: "int8"}},
+							},
+						},
+					},
+				}
+				const mockBaseGo = `
+package sqlc
+
+import (
+	"context"
+	"database/sql"
+)
+
+type DBTX interface {
+	ExecContext(context.Context, string, ...any) (sql.Result, error)
+	QueryContext(context.Context, string, ...any) (*sql.Rows, error)
+}
+
+type Queries struct {
+	db DBTX
+}
+
+const insertUser = "INSERT INTO users (id, name) VALUES ($1, $2) RETURNING id"
+
+type InsertUserParams struct {
+	ID   any
+	Name any
+}
+`
+				return Args{req: req}, Expected{
+					fileCount: 1,
+					contains: []string{
+						"func (q *Queries) BulkInsertUser(ctx context.Context, args BulkInsertUserParams) ([]int64, error) {",
+						"func (q *Queries) BulkInsertUserTx(ctx context.Context, args BulkInsertUserParams) ([]int64, error) {",
+						"rows, err := db.QueryContext(ctx, bulkSQL, chunkValues...)",
+						"if err := rows.Scan(&i); err != nil {",
+					},
+					notContains: []string{"chunkSize = min(chunkSize, bulkOrderedChunkSize)"},
+					mockBaseGo:  mockBaseGo,
+					err:         nil,
+				}
+			},
+		},
+		"valid:RETURNING all columns of the table": {
+			arrange: func(t *testing.T) (Args, Expected) {
+				usersTable := &plugin.Identifier{Name: "users"}
+				req := &plugin.GenerateRequest{
+					SqlcVersion:   "1.0.0",
+					Settings:      &plugin.Settings{Engine: "postgresql"},
+					PluginOptions: []byte(`{"package": "sqlc"}`),
+					Catalog: &plugin.Catalog{
+						DefaultSchema: "public",
+						Schemas: []*plugin.Schema{
+							{
+								Name: "public",
+								Tables: []*plugin.Table{
+									{
+										Rel: &plugin.Identifier{Name: "users"},
+										Columns: []*plugin.Column{
+											{Name: "id", NotNull: true, Type: &plugin.Identifier{Name: "int8"}},
+											{Name: "name", NotNull: true, Type: &plugin.Identifier{Name: "text"}},
+										},
+									},
+								},
+							},
+						},
+					},
+					Queries: []*plugin.Query{
+						{
+							Name: "InsertUser",
+							Cmd:  ":many",
+							Text: "INSERT INTO users (id, name) VALUES ($1, $2) RETURNING id, name",
+							Params: []*plugin.Parameter{
+								{Number: 1, Column: &plugin.Column{Name: "id"}},
+								{Number: 2, Column: &plugin.Column{Name: "name"}},
+							},
+							Columns: []*plugin.Column{
+								{Name: "id", NotNull: true, Table: usersTable, Type: &plugin.Identifier{Name: "int8"}},
+								{Name: "name", NotNull: true, Table: usersTable, Type: &plugin.Identifier{Name: "text"}},
+							},
+						},
+					},
+				}
+				const mockBaseGo = `
+package sqlc
+
+import (
+	"context"
+	"database/sql"
+)
+
+type DBTX interface {
+	ExecContext(context.Context, string, ...any) (sql.Result, error)
+	QueryContext(context.Context, string, ...any) (*sql.Rows, error)
+}
+
+type Queries struct {
+	db DBTX
+}
+
+const insertUser = "INSERT INTO users (id, name) VALUES ($1, $2) RETURNING id, name"
+
+type InsertUserParams struct {
+	ID   any
+	Name any
+}
+
+type User struct {
+	ID   int64
+	Name string
+}
+`
+				return Args{req: req}, Expected{
+					fileCount: 1,
+					contains: []string{
+						"func (q *Queries) BulkInsertUser(ctx context.Context, args BulkInsertUserParams) ([]User, error) {",
+						"if err := rows.Scan(&i.ID, &i.Name); err != nil {",
+					},
+					mockBaseGo: mockBaseGo,
+					err:        nil,
+				}
+			},
+		},
+		"valid:RETURNING into the XxxRow struct with pq.Array": {
+			arrange: func(t *testing.T) (Args, Expected) {
+				req := &plugin.GenerateRequest{
+					SqlcVersion:   "1.0.0",
+					Settings:      &plugin.Settings{Engine: "postgresql"},
+					PluginOptions: []byte(`{"package": "sqlc"}`),
+					Queries: []*plugin.Query{
+						{
+							Name: "InsertUser",
+							Cmd:  ":many",
+							Text: "INSERT INTO users (id, name) VALUES ($1, $2) RETURNING id, tags",
+							Params: []*plugin.Parameter{
+								{Number: 1, Column: &plugin.Column{Name: "id"}},
+								{Number: 2, Column: &plugin.Column{Name: "name"}},
+							},
+							Columns: []*plugin.Column{
+								{Name: "id", NotNull: true, Type: &plugin.Identifier{Name: "int8"}},
+								{Name: "tags", NotNull: true, IsArray: true, ArrayDims: 1, Type: &plugin.Identifier{Name: "text"}},
+							},
+						},
+					},
+				}
+				const mockBaseGo = `
+package sqlc
+
+import (
+	"context"
+	"database/sql"
+)
+
+type DBTX interface {
+	ExecContext(context.Context, string, ...any) (sql.Result, error)
+	QueryContext(context.Context, string, ...any) (*sql.Rows, error)
+}
+
+type Queries struct {
+	db DBTX
+}
+
+const insertUser = "INSERT INTO users (id, name) VALUES ($1, $2) RETURNING id, tags"
+
+type InsertUserParams struct {
+	ID   any
+	Name any
+}
+
+type InsertUserRow struct {
+	ID   int64
+	Tags []string
+}
+`
+				return Args{req: req}, Expected{
+					fileCount: 1,
+					contains: []string{
+						"\"github.com/lib/pq\"",
+						"func (q *Queries) BulkInsertUser(ctx context.Context, args BulkInsertUserParams) ([]InsertUserRow, error) {",
+						"if err := rows.Scan(&i.ID, pq.Array(&i.Tags)); err != nil {",
+					},
+					mockBaseGo: mockBaseGo,
+					err:        nil,
+				}
+			},
+		},
+		"valid:RETURNING with pgx/v5": {
+			arrange: func(t *testing.T) (Args, Expected) {
+				req := &plugin.GenerateRequest{
+					SqlcVersion:   "1.0.0",
+					Settings:      &plugin.Settings{Engine: "postgresql"},
+					PluginOptions: []byte(`{"package": "sqlc", "sql_package": "pgx/v5", "emit_methods_with_db_argument": true}`),
+					Queries: []*plugin.Query{
+						{
+							Name: "InsertUser",
+							Cmd:  ":many",
+							Text: "INSERT INTO users (id, name) VALUES ($1, $2) RETURNING id",
+							Params: []*plugin.Parameter{
+								{Number: 1, Column: &plugin.Column{Name: "id"}},
+								{Number: 2, Column: &plugin.Column{Name: "name"}},
+							},
+							Columns: []*plugin.Column{
+								{Name: "id", NotNull: true, Type: &plugin.Identifier{Name: "int8"}},
+							},
+						},
+					},
+				}
+				const mockBaseGo = `
+package sqlc
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type DBTX interface {
+	Exec(context.Context, string, ...interface{}) (pgconn.CommandTag, error)
+	Query(context.Context, string, ...interface{}) (pgx.Rows, error)
+}
+
+type Queries struct{}
+
+const insertUser = "INSERT INTO users (id, name) VALUES ($1, $2) RETURNING id"
+
+type InsertUserParams struct {
+	ID   interface{}
+	Name interface{}
+}
+`
+				return Args{req: req}, Expected{
+					fileCount: 1,
+					contains: []string{
+						"func (q *Queries) BulkInsertUserTx(ctx context.Context, db DBTX, args BulkInsertUserParams) ([]int64, error) {",
+						"rows, err := db.Query(ctx, bulkSQL, chunkValues...)",
+						"\t\trows.Close()\n\t\tif err := rows.Err(); err != nil {",
+					},
+					mockBaseGo: mockBaseGo,
+					err:        nil,
+				}
+			},
+		},
+		"valid:preserve_returning_order": {
+			arrange: func(t *testing.T) (Args, Expected) {
+				req := &plugin.GenerateRequest{
+					SqlcVersion:   "1.0.0",
+					Settings:      &plugin.Settings{Engine: "postgresql"},
+					PluginOptions: []byte(`{"package": "sqlc", "preserve_returning_order": true}`),
+					Queries: []*plugin.Query{
+						{
+							Name: "InsertUser",
+							Cmd:  ":one",
+							Text: "INSERT INTO users (id, name) VALUES ($1, $2) RETURNING id",
+							Params: []*plugin.Parameter{
+								{Number: 1, Column: &plugin.Column{Name: "id"}},
+								{Number: 2, Column: &plugin.Column{Name: "name"}},
+							},
+							Columns: []*plugin.Column{
+								{Name: "id", NotNull: true, Type: &plugin.Identifier{Name: "int8"}},
+							},
+						},
+					},
+				}
+				const mockBaseGo = `
+package sqlc
+
+import (
+	"context"
+	"database/sql"
+)
+
+type DBTX interface {
+	ExecContext(context.Context, string, ...any) (sql.Result, error)
+	QueryContext(context.Context, string, ...any) (*sql.Rows, error)
+}
+
+type Queries struct {
+	db DBTX
+}
+
+const insertUser = "INSERT INTO users (id, name) VALUES ($1, $2) RETURNING id"
+
+type InsertUserParams struct {
+	ID   any
+	Name any
+}
+`
+				return Args{req: req}, Expected{
+					fileCount: 1,
+					contains: []string{
+						"items := make([]int64, len(args))",
+						"const bulkOrderedChunkSize = 100",
+						"chunkSize = min(chunkSize, bulkOrderedChunkSize)",
+						"bulkSQL, err := buildOrderedBulkInsertQuery(originalQuery, end-start, numParamsPerArg, 0)",
+						"if err := rows.Scan(&ordinal, &i); err != nil {",
+						"items[start+ordinal] = i",
+					},
+					mockBaseGo: mockBaseGo,
+					err:        nil,
+				}
+			},
+		},
+		"valid:execrows": {
+			arrange: func(t *testing.T) (Args, Expected) {
+				req := &plugin.GenerateRequest{
+					SqlcVersion:   "1.0.0",
+					PluginOptions: []byte(`{"package": "sqlc"}`),
+					Queries: []*plugin.Query{
+						{
+							Name: "InsertUser",
+							Cmd:  ":execrows",
+							Text: "INSERT INTO users (id, name) VALUES (?, ?)",
+							Params: []*plugin.Parameter{
+								{Number: 1, Column: &plugin.Column{Name: "id"}},
+								{Number: 2, Column: &plugin.Column{Name: "name"}},
+							},
+						},
+					},
+				}
+				return Args{req: req}, Expected{
+					fileCount: 1,
+					contains: []string{
+						"func (q *Queries) BulkInsertUser(ctx context.Context, args BulkInsertUserParams) (int64, error) {",
+						"func (q *Queries) BulkInsertUserTx(ctx context.Context, args BulkInsertUserParams) (int64, error) {",
+						"chunkRowsAffected, err := chunkResult.RowsAffected()",
+						"return rowsAffected, nil",
+					},
+					err: nil,
+				}
+			},
+		},
+		"valid:execresult": {
+			arrange: func(t *testing.T) (Args, Expected) {
+				req := &plugin.GenerateRequest{
+					SqlcVersion:   "1.0.0",
+					PluginOptions: []byte(`{"package": "sqlc"}`),
+					Queries: []*plugin.Query{
+						{
+							Name: "InsertUser",
+							Cmd:  ":execresult",
+							Text: "INSERT INTO users (id, name) VALUES (?, ?)",
+							Params: []*plugin.Parameter{
+								{Number: 1, Column: &plugin.Column{Name: "id"}},
+								{Number: 2, Column: &plugin.Column{Name: "name"}},
+							},
+						},
+					},
+				}
+				return Args{req: req}, Expected{
+					fileCount: 1,
+					contains: []string{
+						"type BulkResult struct {",
+						"func (q *Queries) BulkInsertUser(ctx context.Context, args BulkInsertUserParams) (BulkResult, error) {",
+						"result.Results = append(result.Results, chunkResult)",
+					},
+					err: nil,
+				}
+			},
+		},
+		"valid:execrows and execresult with pgx/v5": {
+			arrange: func(t *testing.T) (Args, Expected) {
+				req := &plugin.GenerateRequest{
+					SqlcVersion:   "1.0.0",
+					Settings:      &plugin.Settings{Engine: "postgresql"},
+					PluginOptions: []byte(`{"package": "sqlc", "sql_package": "pgx/v5"}`),
+					Queries: []*plugin.Query{
+						{
+							Name: "InsertUser",
+							Cmd:  ":execrows",
+							Text: "INSERT INTO users (id, name) VALUES ($1, $2)",
+							Params: []*plugin.Parameter{
+								{Number: 1, Column: &plugin.Column{Name: "id"}},
+								{Number: 2, Column: &plugin.Column{Name: "name"}},
+							},
+						},
+						{
+							Name: "InsertTeam",
+							Cmd:  ":execresult",
+							Text: "INSERT INTO teams (id, name) VALUES ($1, $2)",
+							Params: []*plugin.Parameter{
+								{Number: 1, Column: &plugin.Column{Name: "id"}},
+								{Number: 2, Column: &plugin.Column{Name: "name"}},
+							},
+						},
+					},
+				}
+				const mockBaseGo = `
+package sqlc
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type DBTX interface {
+	Exec(context.Context, string, ...interface{}) (pgconn.CommandTag, error)
+}
+
+type Queries struct {
+	db DBTX
+}
+
+const insertUser = "INSERT INTO users (id, name) VALUES ($1, $2)"
+
+type InsertUserParams struct {
+	ID   interface{}
+	Name interface{}
+}
+
+const insertTeam = "INSERT INTO teams (id, name) VALUES ($1, $2)"
+
+type InsertTeamParams struct {
+	ID   interface{}
+	Name interface{}
+}
+`
+				return Args{req: req}, Expected{
+					fileCount: 1,
+					contains: []string{
+						"\"github.com/jackc/pgx/v5/pgconn\"",
+						"CommandTags []pgconn.CommandTag",
+						"rowsAffected += chunkResult.RowsAffected()",
+						"result.CommandTags = append(result.CommandTags, chunkResult)",
+					},
+					mockBaseGo: mockBaseGo,
+					err:        nil,
+				}
+			},
+		},
+		"valid:execresult, emit_copy_functions and emit_batch_functions with pgx/v4": {
+			arrange: func(t *testing.T) (Args, Expected) {
+				req := &plugin.GenerateRequest{
+					SqlcVersion:   "1.0.0",
+					Settings:      &plugin.Settings{Engine: "postgresql"},
+					PluginOptions: []byte(`{"package": "sqlc", "sql_package": "pgx/v4", "emit_copy_functions": true, "emit_batch_functions": true}`),
+					Queries: []*plugin.Query{
+						{
+							Name:            "InsertUser",
+							Cmd:             ":execresult",
+							Text:            "INSERT INTO users (id, name) VALUES ($1, $2)",
+							InsertIntoTable: &plugin.Identifier{Name: "users"},
+							Params: []*plugin.Parameter{
+								{Number: 1, Column: &plugin.Column{Name: "id"}},
+								{Number: 2, Column: &plugin.Column{Name: "name"}},
+							},
+						},
+						{
+							Name: "UpsertUser",
+							Text: "INSERT INTO users (id, name) VALUES ($2, $3) ON CONFLICT (id) DO UPDATE SET name = EXCLUDED.name, updated_by = $1",
+							Params: []*plugin.Parameter{
+								{Number: 1, Column: &plugin.Column{Name: "updated_by", NotNull: true, Type: &plugin.Identifier{Name: "text"}}},
+								{Number: 2, Column: &plugin.Column{Name: "id"}},
+								{Number: 3, Column: &plugin.Column{Name: "name"}},
+							},
+						},
+					},
+				}
+				const mockBaseGo = `
+package sqlc
+
+import (
+	"context"
+
+	"github.com/jackc/pgconn"
+)
+
+type DBTX interface {
+	Exec(context.Context, string, ...interface{}) (pgconn.CommandTag, error)
+}
+
+type Queries struct {
+	db DBTX
+}
+
+const insertUser = "INSERT INTO users (id, name) VALUES ($1, $2)"
+
+type InsertUserParams struct {
+	ID   interface{}
+	Name interface{}
+}
+
+const upsertUser = "INSERT INTO users (id, name) VALUES ($2, $3) ON CONFLICT (id) DO UPDATE SET name = EXCLUDED.name, updated_by = $1"
+
+type UpsertUserParams struct {
+	ID   interface{}
+	Name interface{}
+}
+`
+				return Args{req: req}, Expected{
+					fileCount: 1,
+					contains: []string{
+						"\"github.com/jackc/pgconn\"",
+						"\"github.com/jackc/pgx/v4\"",
+						"CommandTags []pgconn.CommandTag",
+						"func (q *Queries) BulkInsertUserCopy(ctx context.Context, args BulkInsertUserParams) (int64, error) {",
+						"func (q *Queries) BulkUpsertUserBatch(ctx context.Context, args BulkUpsertUserParams, stmtUpdatedBy string) error {",
+					},
+					notContains: []string{"\"github.com/jackc/pgx/v5"},
+					mockBaseGo:  mockBaseGo,
+					err:         nil,
+				}
+			},
+		},
+		"valid:MySQL execlastid": {
+			arrange: func(t *testing.T) (Args, Expected) {
+				req := &plugin.GenerateRequest{
+					SqlcVersion:   "1.0.0",
+					Settings:      &plugin.Settings{Engine: "mysql"},
+					PluginOptions: []byte(`{"package": "sqlc"}`),
+					Queries: []*plugin.Query{
+						{
+							Name: "InsertUser",
+							Cmd:  ":execlastid",
+							Text: "INSERT INTO users (id, name) VALUES (?, ?)",
+							Params: []*plugin.Parameter{
+								{Number: 1, Column: &plugin.Column{Name: "id"}},
+								{Number: 2, Column: &plugin.Column{Name: "name"}},
+							},
+						},
+					},
+				}
+				const mockBaseGo = `
+package sqlc
+
+import (
+	"context"
+	"database/sql"
+)
+
+type DBTX interface {
+	ExecContext(context.Context, string, ...interface{}) (sql.Result, error)
+	QueryRowContext(context.Context, string, ...interface{}) *sql.Row
+}
+
+type Queries struct {
+	db DBTX
+}
+
+const insertUser = "INSERT INTO users (id, name) VALUES (?, ?)"
+
+type InsertUserParams struct {
+	ID   interface{}
+	Name interface{}
+}
+`
+				return Args{req: req}, Expected{
+					fileCount: 1,
+					contains: []string{
+						"func (q *Queries) BulkInsertUser(ctx context.Context, args BulkInsertUserParams) ([]int64, error) {",
+						"func bulkAutoIncrementIncrement(ctx context.Context, db DBTX) (int64, error) {",
+						"autoIncrementIncrement, err := bulkAutoIncrementIncrement(ctx, db)",
+						"ids = append(ids, firstID+j*autoIncrementIncrement)",
+					},
+					mockBaseGo: mockBaseGo,
+					err:        nil,
+				}
+			},
+		},
+		"valid:MySQL execlastid with skip_auto_increment_check": {
+			arrange: func(t *testing.T) (Args, Expected) {
+				req := &plugin.GenerateRequest{
+					SqlcVersion:   "1.0.0",
+					Settings:      &plugin.Settings{Engine: "mysql"},
+					PluginOptions: []byte(`{"package": "sqlc", "skip_auto_increment_check": true}`),
+					Queries: []*plugin.Query{
+						{
+							Name: "InsertUser",
+							Cmd:  ":execlastid",
+							Text: "INSERT INTO users (id, name) VALUES (?, ?)",
+							Params: []*plugin.Parameter{
+								{Number: 1, Column: &plugin.Column{Name: "id"}},
+								{Number: 2, Column: &plugin.Column{Name: "name"}},
+							},
+						},
+					},
+				}
+				return Args{req: req}, Expected{
+					fileCount: 1,
+					contains: []string{
+						"func (q *Queries) BulkInsertUserTx(ctx context.Context, args BulkInsertUserParams) ([]int64, error) {",
+						"autoIncrementIncrement := int64(1)",
+					},
+					err: nil,
+				}
+			},
+		},
+		"valid:overrides and emit_pointers_for_null_types": {
+			arrange: func(t *testing.T) (Args, Expected) {
+				req := &plugin.GenerateRequest{
+					SqlcVersion:   "1.0.0",
+					Settings:      &plugin.Settings{Engine: "postgresql"},
+					PluginOptions: []byte(`{"package": "sqlc", "sql_package": "pgx/v5", "query_parameter_limit": 2, "emit_pointers_for_null_types": true}`),
+					GlobalOptions: []byte(`{"overrides": [{"db_type": "uuid", "go_type": "github.com/google/uuid.UUID"}]}`),
+					Queries: []*plugin.Query{
+						{
+							Name:   "InsertUserID",
+							Text:   "INSERT INTO users (id) VALUES ($1)",
+							Params: []*plugin.Parameter{{Number: 1, Column: &plugin.Column{Name: "id", NotNull: true, Type: &plugin.Identifier{Name: "uuid"}}}},
+						},
+						{
+							Name: "InsertNote",
+							Text: "INSERT INTO notes (id, body) VALUES ($1, $2)",
+							Params: []*plugin.Parameter{
+								{Number: 1, Column: &plugin.Column{Name: "id", NotNull: true, Type: &plugin.Identifier{Name: "uuid"}}},
+								{Number: 2, Column: &plugin.Column{Name: "body", Type: &plugin.Identifier{Name: "text"}}},
+							},
+						},
+					},
+				}
+				const mockBaseGo = `
+package sqlc
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type DBTX interface {
+	Exec(context.Context, string, ...interface{}) (pgconn.CommandTag, error)
+}
+
+type Queries struct {
+	db DBTX
+}
+
+const insertUserID = "INSERT INTO users (id) VALUES ($1)"
+
+const insertNote = "INSERT INTO notes (id, body) VALUES ($1, $2)"
+`
+				return Args{req: req}, Expected{
+					fileCount: 1,
+					contains: []string{
+						"\"github.com/google/uuid\"",
+						"type BulkInsertUserIDParams []uuid.UUID",
+						"type BulkInsertNoteRow struct {\n\tID   uuid.UUID\n\tBody *string\n}",
+					},
+					notContains: []string{"pgtype"},
+					mockBaseGo:  mockBaseGo,
+					err:         nil,
+				}
+			},
+		},
+		"valid:bulk_mode unnest": {
+			arrange: func(t *testing.T) (Args, Expected) {
+				req := &plugin.GenerateRequest{
+					SqlcVersion:   "1.0.0",
+					Settings:      &plugin.Settings{Engine: "postgresql"},
+					PluginOptions: []byte(`{"package": "sqlc", "bulk_mode": "unnest", "batch_size": 1000}`),
+					Queries: []*plugin.Query{
+						{
+							Name: "UpsertUser",
+							Text: "INSERT INTO users (id, name) VALUES ($1, $2) ON CONFLICT (id) DO UPDATE SET updated_by = $3",
+							Params: []*plugin.Parameter{
+								{Number: 1, Column: &plugin.Column{Name: "id", NotNull: true, Type: &plugin.Identifier{Name: "int8"}}},
+								{Number: 2, Column: &plugin.Column{Name: "name", Type: &plugin.Identifier{Name: "text"}}},
+								{Number: 3, Column: &plugin.Column{Name: "updated_by", NotNull: true, Type: &plugin.Identifier{Name: "text"}}},
+							},
+						},
+					},
+				}
+				const mockBaseGo = `
+package sqlc
+
+import (
+	"context"
+	"database/sql"
+)
+
+type DBTX interface {
+	ExecContext(context.Context, string, ...any) (sql.Result, error)
+}
+
+type Queries struct {
+	db DBTX
+}
+
+const upsertUser = "INSERT INTO users (id, name) VALUES ($1, $2) ON CONFLICT (id) DO UPDATE SET updated_by = $3"
+
+type UpsertUserParams struct {
+	ID        int64
+	Name      sql.NullString
+	UpdatedBy string
+}
+`
+				return Args{req: req}, Expected{
+					fileCount: 1,
+					contains: []string{
+						"\"github.com/lib/pq\"",
+						"const bulkUnnestUpsertUser = `INSERT INTO users (id, name) SELECT bulk_unnest.c1, bulk_unnest.c2 " +
+							"FROM unnest($1::int8[], $2::text[]) AS bulk_unnest(c1, c2) ON CONFLICT (id) DO UPDATE SET updated_by = $3`",
+						"chunkSize := 1000",
+						"unnestColumn1 := make([]sql.NullString, 0, end-start)",
+						"unnestColumn1 = append(unnestColumn1, arg.Name)",
+						"chunkValues := []any{pq.Array(unnestColumn0), pq.Array(unnestColumn1)}",
+					},
+					mockBaseGo: mockBaseGo,
+					err:        nil,
+				}
+			},
+		},
+		"valid:bulk_mode unnest with a statement-level parameter named like a builtin": {
+			arrange: func(t *testing.T) (Args, Expected) {
+				req := &plugin.GenerateRequest{
+					SqlcVersion:   "1.0.0",
+					Settings:      &plugin.Settings{Engine: "postgresql"},
+					PluginOptions: []byte(`{"package": "sqlc", "bulk_mode": "unnest"}`),
+					Queries: []*plugin.Query{
+						{
+							Name: "UpsertLimit",
+							Text: "INSERT INTO limits (id, name) VALUES ($1, $2) ON CONFLICT (id) DO UPDATE SET min = $3",
+							Params: []*plugin.Parameter{
+								{Number: 1, Column: &plugin.Column{Name: "id", NotNull: true, Type: &plugin.Identifier{Name: "int8"}}},
+								{Number: 2, Column: &plugin.Column{Name: "name", NotNull: true, Type: &plugin.Identifier{Name: "text"}}},
+								{Number: 3, Column: &plugin.Column{Name: "min", NotNull: true, Type: &plugin.Identifier{Name: "int8"}}},
+							},
+						},
+					},
+				}
+				const mockBaseGo = `
+package sqlc
+
+import (
+	"context"
+	"database/sql"
+)
+
+type DBTX interface {
+	ExecContext(context.Context, string, ...any) (sql.Result, error)
+}
+
+type Queries struct {
+	db DBTX
+}
+
+const upsertLimit = "INSERT INTO limits (id, name) VALUES ($1, $2) ON CONFLICT (id) DO UPDATE SET min = $3"
+
+type UpsertLimitParams struct {
+	ID   int64
+	Name string
+	Min  int64
+}
+`
+				return Args{req: req}, Expected{
+					fileCount: 1,
+					contains: []string{
+						"args BulkUpsertLimitParams, stmtMin int64) error {",
+						"end := min(start+chunkSize, len(args))",
+					},
+					mockBaseGo: mockBaseGo,
+					err:        nil,
+				}
+			},
+		},
+		"valid:bulk_mode unnest casts serial columns to integer arrays": {
+			arrange: func(t *testing.T) (Args, Expected) {
+				req := &plugin.GenerateRequest{
+					SqlcVersion:   "1.0.0",
+					Settings:      &plugin.Settings{Engine: "postgresql"},
+					PluginOptions: []byte(`{"package": "sqlc", "bulk_mode": "unnest"}`),
+					Queries: []*plugin.Query{
+						{
+							Name: "InsertCounter",
+							Text: "INSERT INTO counters (a, b, c, d) VALUES ($1, $2, $3, $4)",
+							Params: []*plugin.Parameter{
+								{Number: 1, Column: &plugin.Column{Name: "a", NotNull: true, Type: &plugin.Identifier{Name: "serial"}}},
+								{Number: 2, Column: &plugin.Column{Name: "b", NotNull: true, Type: &plugin.Identifier{Name: "bigserial"}}},
+								{Number: 3, Column: &plugin.Column{Name: "c", NotNull: true, Type: &plugin.Identifier{Name: "smallserial"}}},
+								{Number: 4, Column: &plugin.Column{Name: "d", NotNull: true, Type: &plugin.Identifier{Schema: "pg_catalog", Name: "serial8"}}},
+							},
+						},
+					},
+				}
+				const mockBaseGo = `
+package sqlc
+
+import (
+	"context"
+	"database/sql"
+)
+
+type DBTX interface {
+	ExecContext(context.Context, string, ...any) (sql.Result, error)
+}
+
+type Queries struct {
+	db DBTX
+}
+
+const insertCounter = "INSERT INTO counters (a, b, c, d) VALUES ($1, $2, $3, $4)"
+
+type InsertCounterParams struct {
+	A int32
+	B int64
+	C int16
+	D int64
+}
+`
+				return Args{req: req}, Expected{
+					fileCount: 1,
+					contains: []string{
+						"FROM unnest($1::int4[], $2::int8[], $3::int2[], $4::int8[])",
+					},
+					notContains: []string{"serial"},
+					mockBaseGo:  mockBaseGo,
+					err:         nil,
+				}
+			},
+		},
+		"valid:bulk_mode unnest with pgx/v5 and a single parameter": {
+			arrange: func(t *testing.T) (Args, Expected) {
+				req := &plugin.GenerateRequest{
+					SqlcVersion:   "1.0.0",
+					Settings:      &plugin.Settings{Engine: "postgresql"},
+					PluginOptions: []byte(`{"package": "sqlc", "sql_package": "pgx/v5", "bulk_mode": "unnest"}`),
+					Queries: []*plugin.Query{
+						{
+							Name:   "InsertTag",
+							Cmd:    ":execrows",
+							Text:   "INSERT INTO tags (name) VALUES (lower($1))",
+							Params: []*plugin.Parameter{{Number: 1, Column: &plugin.Column{Name: "name", NotNull: true, Type: &plugin.Identifier{Name: "text"}}}},
+						},
+					},
+				}
+				const mockBaseGo = `
+package sqlc
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type DBTX interface {
+	Exec(context.Context, string, ...interface{}) (pgconn.CommandTag, error)
+}
+
+type Queries struct {
+	db DBTX
+}
+
+const insertTag = "INSERT INTO tags (name) VALUES (lower($1))"
+`
+				return Args{req: req}, Expected{
+					fileCount: 1,
+					contains: []string{
+						"SELECT lower(bulk_unnest.c1) FROM unnest($1::text[]) AS bulk_unnest(c1)`",
+						"chunkSize := len(args)",
+						"unnestColumn0 = append(unnestColumn0, arg)",
+						"chunkValues := []any{unnestColumn0}",
+					},
+					mockBaseGo: mockBaseGo,
+					err:        nil,
+				}
+			},
+		},
+		"valid:bulk_mode unnest imports the element types with pgx/v5": {
+			arrange: func(t *testing.T) (Args, Expected) {
+				req := &plugin.GenerateRequest{
+					SqlcVersion:   "1.0.0",
+					Settings:      &plugin.Settings{Engine: "postgresql"},
+					PluginOptions: []byte(`{"package": "sqlc", "sql_package": "pgx/v5", "bulk_mode": "unnest"}`),
+					Queries: []*plugin.Query{
+						{
+							Name: "InsertEvent",
+							Text: "INSERT INTO events (name, created_at) VALUES ($1, $2)",
+							Params: []*plugin.Parameter{
+								{Number: 1, Column: &plugin.Column{Name: "name", Type: &plugin.Identifier{Name: "text"}}},
+								{Number: 2, Column: &plugin.Column{Name: "created_at", NotNull: true, Type: &plugin.Identifier{Name: "timestamptz"}}},
+							},
+						},
+					},
+				}
+				const mockBaseGo = `
+package sqlc
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type DBTX interface {
+	Exec(context.Context, string, ...interface{}) (pgconn.CommandTag, error)
+}
+
+type Queries struct {
+	db DBTX
+}
+
+const insertEvent = "INSERT INTO events (name, created_at) VALUES ($1, $2)"
+
+type InsertEventParams struct {
+	Name      pgtype.Text
+	CreatedAt pgtype.Timestamptz
+}
+`
+				return Args{req: req}, Expected{
+					fileCount: 1,
+					contains: []string{
+						"\"github.com/jackc/pgx/v5/pgtype\"",
+						"unnestColumn0 := make([]pgtype.Text, 0, end-start)",
+						"unnestColumn1 := make([]pgtype.Timestamptz, 0, end-start)",
+					},
+					mockBaseGo: mockBaseGo,
+					err:        nil,
+				}
+			},
+		},
+		"valid:bulk_mode unnest imports time": {
+			arrange: func(t *testing.T) (Args, Expected) {
+				req := &plugin.GenerateRequest{
+					SqlcVersion:   "1.0.0",
+					Settings:      &plugin.Settings{Engine: "postgresql"},
+					PluginOptions: []byte(`{"package": "sqlc", "bulk_mode": "unnest"}`),
+					Queries: []*plugin.Query{
+						{
+							Name: "InsertEvent",
+							Text: "INSERT INTO events (id, created_at) VALUES ($1, $2)",
+							Params: []*plugin.Parameter{
+								{Number: 1, Column: &plugin.Column{Name: "id", NotNull: true, Type: &plugin.Identifier{Name: "int8"}}},
+								{Number: 2, Column: &plugin.Column{Name: "created_at", NotNull: true, Type: &plugin.Identifier{Name: "timestamptz"}}},
+							},
+						},
+					},
+				}
+				const mockBaseGo = `
+package sqlc
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+type DBTX interface {
+	ExecContext(context.Context, string, ...any) (sql.Result, error)
+}
+
+type Queries struct {
+	db DBTX
+}
+
+const insertEvent = "INSERT INTO events (id, created_at) VALUES ($1, $2)"
+
+type InsertEventParams struct {
+	ID        int64
+	CreatedAt time.Time
+}
+`
+				return Args{req: req}, Expected{
+					fileCount: 1,
+					contains: []string{
+						"\t\"time\"\n",
+						"unnestColumn1 := make([]time.Time, 0, end-start)",
+					},
+					mockBaseGo: mockBaseGo,
+					err:        nil,
+				}
+			},
+		},
+		"valid:bulk_mode unnest falls back for json.RawMessage with database/sql": {
+			arrange: func(t *testing.T) (Args, Expected) {
+				req := &plugin.GenerateRequest{
+					SqlcVersion:   "1.0.0",
+					Settings:      &plugin.Settings{Engine: "postgresql"},
+					PluginOptions: []byte(`{"package": "sqlc", "bulk_mode": "unnest"}`),
+					Queries: []*plugin.Query{
+						{
+							Name: "InsertDocument",
+							Text: "INSERT INTO documents (id, body) VALUES ($1, $2)",
+							Params: []*plugin.Parameter{
+								{Number: 1, Column: &plugin.Column{Name: "id", NotNull: true, Type: &plugin.Identifier{Name: "int8"}}},
+								{Number: 2, Column: &plugin.Column{Name: "body", NotNull: true, Type: &plugin.Identifier{Name: "jsonb"}}},
+							},
+						},
+					},
+				}
+				const mockBaseGo = `
+package sqlc
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+)
+
+type DBTX interface {
+	ExecContext(context.Context, string, ...any) (sql.Result, error)
+}
+
+type Queries struct {
+	db DBTX
+}
+
+const insertDocument = "INSERT INTO documents (id, body) VALUES ($1, $2)"
+
+type InsertDocumentParams struct {
+	ID   int64
+	Body json.RawMessage
+}
+`
+				return Args{req: req}, Expected{
+					fileCount:   1,
+					contains:    []string{"range bulkChunkEnds(originalQuery, preparedValues, nil, numParamsPerArg, chunkSize, 0) {"},
+					notContains: []string{"bulkUnnestInsertDocument", "[]json.RawMessage"},
+					mockBaseGo:  mockBaseGo,
+					err:         nil,
+				}
+			},
+		},
+		"valid:bulk_mode unnest falls back for array parameters": {
+			arrange: func(t *testing.T) (Args, Expected) {
+				req := &plugin.GenerateRequest{
+					SqlcVersion:   "1.0.0",
+					Settings:      &plugin.Settings{Engine: "postgresql"},
+					PluginOptions: []byte(`{"package": "sqlc", "bulk_mode": "unnest"}`),
+					Queries: []*plugin.Query{
+						{
+							Name: "InsertUser",
+							Text: "INSERT INTO users (id, name) VALUES ($1, $2)",
+							Params: []*plugin.Parameter{
+								{Number: 1, Column: &plugin.Column{Name: "id"}},
+								{Number: 2, Column: &plugin.Column{Name: "name", IsArray: true, ArrayDims: 1, Type: &plugin.Identifier{Name: "text"}}},
+							},
+						},
+					},
+				}
+				return Args{req: req}, Expected{
+					fileCount: 1,
+					contains:  []string{"range bulkChunkEnds(originalQuery, preparedValues, nil, numParamsPerArg, chunkSize, 0) {"},
+					err:       nil,
+				}
+			},
+		},
+		"valid:emit_copy_functions": {
+			arrange: func(t *testing.T) (Args, Expected) {
+				req := &plugin.GenerateRequest{
+					SqlcVersion:   "1.0.0",
+					Settings:      &plugin.Settings{Engine: "postgresql"},
+					PluginOptions: []byte(`{"package": "sqlc", "sql_package": "pgx/v5", "emit_copy_functions": true}`),
+					Queries: []*plugin.Query{
+						{
+							Name:            "InsertUser",
+							Text:            "INSERT INTO app.users (id, \"Name\") VALUES ($2, $1)",
+							InsertIntoTable: &plugin.Identifier{Schema: "app", Name: "users"},
+							Params: []*plugin.Parameter{
+								{Number: 1, Column: &plugin.Column{Name: "Name"}},
+								{Number: 2, Column: &plugin.Column{Name: "id"}},
+							},
+						},
+						{
+							Name:            "UpsertUser",
+							Text:            "INSERT INTO app.users (id, \"Name\") VALUES ($1, $2) ON CONFLICT (id) DO NOTHING",
+							InsertIntoTable: &plugin.Identifier{Schema: "app", Name: "users"},
+							Params: []*plugin.Parameter{
+								{Number: 1, Column: &plugin.Column{Name: "id"}},
+								{Number: 2, Column: &plugin.Column{Name: "Name"}},
+							},
+						},
+					},
+				}
+				const mockBaseGo = `
+package sqlc
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type DBTX interface {
+	Exec(context.Context, string, ...interface{}) (pgconn.CommandTag, error)
+}
+
+type Queries struct {
+	db DBTX
+}
+
+const insertUser = "INSERT INTO app.users (id, \"Name\") VALUES ($2, $1)"
+
+type InsertUserParams struct {
+	Name interface{}
+	ID   interface{}
+}
+
+const upsertUser = "INSERT INTO app.users (id, \"Name\") VALUES ($1, $2) ON CONFLICT (id) DO NOTHING"
+
+type UpsertUserParams struct {
+	ID   interface{}
+	Name interface{}
+}
+`
+				return Args{req: req}, Expected{
+					fileCount: 1,
+					contains: []string{
+						"func (q *Queries) BulkInsertUserCopy(ctx context.Context, args BulkInsertUserParams) (int64, error) {",
+						"return copier.CopyFrom(ctx, pgx.Identifier{\"app\", \"users\"}, []string{\"id\", \"Name\"}, &iteratorForBulkInsertUser{rows: args})",
+						"\t\tr.rows[0].ID,\n\t\tr.rows[0].Name,\n",
+					},
+					// COPY cannot run an upsert
+					notContains: []string{"BulkUpsertUserCopy"},
+					mockBaseGo:  mockBaseGo,
+					err:         nil,
+				}
+			},
+		},
+		"valid:emit_copy_functions with the columns of the catalog": {
+			arrange: func(t *testing.T) (Args, Expected) {
+				req := &plugin.GenerateRequest{
+					SqlcVersion:   "1.0.0",
+					Settings:      &plugin.Settings{Engine: "postgresql"},
+					PluginOptions: []byte(`{"package": "sqlc", "sql_package": "pgx/v5", "emit_copy_functions": true, "emit_methods_with_db_argument": true}`),
+					Catalog: &plugin.Catalog{
+						DefaultSchema: "public",
+						Schemas: []*plugin.Schema{
+							{
+								Name: "public",
+								Tables: []*plugin.Table{
+									{
+										Rel:     &plugin.Identifier{Name: "tags"},
+										Columns: []*plugin.Column{{Name: "name"}},
+									},
+								},
+							},
+						},
+					},
+					Queries: []*plugin.Query{
+						{
+							Name:            "InsertTag",
+							Text:            "INSERT INTO tags VALUES ($1)",
+							InsertIntoTable: &plugin.Identifier{Name: "tags"},
+							Params:          []*plugin.Parameter{{Number: 1, Column: &plugin.Column{Name: "name", NotNull: true, Type: &plugin.Identifier{Name: "text"}}}},
+						},
+					},
+				}
+				const mockBaseGo = `
+package sqlc
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type DBTX interface {
+	Exec(context.Context, string, ...interface{}) (pgconn.CommandTag, error)
+}
+
+type Queries struct{}
+
+const insertTag = "INSERT INTO tags VALUES ($1)"
+`
+				return Args{req: req}, Expected{
+					fileCount: 1,
+					contains: []string{
+						"func (q *Queries) BulkInsertTagCopy(ctx context.Context, db DBTX, args BulkInsertTagParams) (int64, error) {",
+						"return copier.CopyFrom(ctx, pgx.Identifier{\"tags\"}, []string{\"name\"}, &iteratorForBulkInsertTag{rows: args})",
+						"\t\tr.rows[0],\n",
+					},
+					mockBaseGo: mockBaseGo,
+					err:        nil,
+				}
+			},
+		},
+		"valid:emit_batch_functions with statement-level parameters named like builtins, packages and locals": {
+			arrange: func(t *testing.T) (Args, Expected) {
+				req := &plugin.GenerateRequest{
+					SqlcVersion:   "1.0.0",
+					Settings:      &plugin.Settings{Engine: "postgresql"},
+					PluginOptions: []byte(`{"package": "sqlc", "sql_package": "pgx/v5", "emit_batch_functions": true}`),
+					Queries: []*plugin.Query{
+						{
+							Name: "UpsertFlag",
+							Text: "INSERT INTO flags (id) VALUES ($1) ON CONFLICT (id) DO UPDATE SET ok = $2, min = $3, pq = $4, \"updated-by\" = $5",
+							Params: []*plugin.Parameter{
+								{Number: 1, Column: &plugin.Column{Name: "id", NotNull: true, Type: &plugin.Identifier{Name: "int8"}}},
+								{Number: 2, Column: &plugin.Column{Name: "ok", NotNull: true, Type: &plugin.Identifier{Name: "text"}}},
+								{Number: 3, Column: &plugin.Column{Name: "min", NotNull: true, Type: &plugin.Identifier{Name: "text"}}},
+								{Number: 4, Column: &plugin.Column{Name: "pq", NotNull: true, Type: &plugin.Identifier{Name: "text"}}},
+								{Number: 5, Column: &plugin.Column{Name: "updated-by", NotNull: true, Type: &plugin.Identifier{Name: "text"}}},
+							},
+						},
+					},
+				}
+				const mockBaseGo = `
+package sqlc
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type DBTX interface {
+	Exec(context.Context, string, ...interface{}) (pgconn.CommandTag, error)
+}
+
+type Queries struct {
+	db DBTX
+}
+
+const upsertFlag = "INSERT INTO flags (id) VALUES ($1) ON CONFLICT (id) DO UPDATE SET ok = $2, min = $3, pq = $4, \"updated-by\" = $5"
+
+type UpsertFlagParams struct {
+	ID        int64
+	Ok        string
+	Min       string
+	Pq        string
+	UpdatedBy string
+}
+`
+				return Args{req: req}, Expected{
+					fileCount: 1,
+					contains: []string{
+						"func (q *Queries) BulkUpsertFlagBatch(ctx context.Context, args BulkUpsertFlagParams, stmtOk string, stmtMin string, stmtPq string, stmtUpdatedBy string) error {",
+						"batch.Queue(upsertFlag, arg.ID, stmtOk, stmtMin, stmtPq, stmtUpdatedBy)",
+					},
+					mockBaseGo: mockBaseGo,
+					err:        nil,
+				}
+			},
+		},
+		"valid:emit_batch_functions": {
+			arrange: func(t *testing.T) (Args, Expected) {
+				req := &plugin.GenerateRequest{
+					SqlcVersion:   "1.0.0",
+					Settings:      &plugin.Settings{Engine: "postgresql"},
+					PluginOptions: []byte(`{"package": "sqlc", "sql_package": "pgx/v5", "emit_batch_functions": true}`),
+					Queries: []*plugin.Query{
+						{
+							Name: "UpsertUser",
+							Text: "INSERT INTO users (id, name) VALUES ($2, $3) ON CONFLICT (id) DO UPDATE SET name = EXCLUDED.name, updated_by = $1",
+							Params: []*plugin.Parameter{
+								{Number: 1, Column: &plugin.Column{Name: "updated_by", NotNull: true, Type: &plugin.Identifier{Name: "text"}}},
+								{Number: 2, Column: &plugin.Column{Name: "id"}},
+								{Number: 3, Column: &plugin.Column{Name: "name"}},
+							},
+						},
+						{
+							Name:    "InsertUserReturning",
+							Cmd:     ":many",
+							Text:    "INSERT INTO users (id) VALUES ($1) RETURNING id",
+							Params:  []*plugin.Parameter{{Number: 1, Column: &plugin.Column{Name: "id", NotNull: true, Type: &plugin.Identifier{Name: "int8"}}}},
+							Columns: []*plugin.Column{{Name: "id", NotNull: true, Type: &plugin.Identifier{Name: "int8"}}},
+						},
+					},
+				}
+				const mockBaseGo = `
+package sqlc
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type DBTX interface {
+	Exec(context.Context, string, ...interface{}) (pgconn.CommandTag, error)
+	Query(context.Context, string, ...interface{}) (pgx.Rows, error)
+}
+
+type Queries struct {
+	db DBTX
+}
+
+const upsertUser = "INSERT INTO users (id, name) VALUES ($2, $3) ON CONFLICT (id) DO UPDATE SET name = EXCLUDED.name, updated_by = $1"
+
+type UpsertUserParams struct {
+	ID   interface{}
+	Name interface{}
+}
+
+const insertUserReturning = "INSERT INTO users (id) VALUES ($1) RETURNING id"
+`
+				return Args{req: req}, Expected{
+					fileCount: 1,
+					contains: []string{
+						"type BulkBatchError map[int]error",
+						"func (q *Queries) BulkUpsertUserBatch(ctx context.Context, args BulkUpsertUserParams, stmtUpdatedBy string) error {",
+						"batch.Queue(upsertUser, stmtUpdatedBy, arg.ID, arg.Name)",
+						"batchErr[i] = err",
+					},
+					// The rows returned by a batch are not collected
+					notContains: []string{"BulkInsertUserReturningBatch"},
+					mockBaseGo:  mockBaseGo,
+					err:         nil,
+				}
+			},
+		},
+		"valid:emit_seq_functions": {
+			arrange: func(t *testing.T) (Args, Expected) {
+				req := &plugin.GenerateRequest{
+					SqlcVersion:   "1.0.0",
+					Settings:      &plugin.Settings{Engine: "sqlite"},
+					PluginOptions: []byte(`{"package": "sqlc", "emit_seq_functions": true, "emit_methods_with_db_argument": true, "batch_size": 100}`),
+					Queries: []*plugin.Query{
+						{
+							Name: "InsertUser",
+							Cmd:  ":execrows",
+							Text: "INSERT INTO users (id, name) VALUES (?, ?)",
+							Params: []*plugin.Parameter{
+								{Number: 1, Column: &plugin.Column{Name: "id"}},
+								{Number: 2, Column: &plugin.Column{Name: "name"}},
+							},
+						},
+						{
+							Name: "InsertTag",
+							Text: "INSERT INTO tags (name) VALUES (?)",
+							Params: []*plugin.Parameter{
+								{Number: 1, Column: &plugin.Column{Name: "name", NotNull: true, Type: &plugin.Identifier{Name: "text"}}},
+							},
+						},
+						{
+							Name:    "InsertUserReturning",
+							Cmd:     ":many",
+							Text:    "INSERT INTO users (id) VALUES (?) RETURNING id",
+							Params:  []*plugin.Parameter{{Number: 1, Column: &plugin.Column{Name: "id", NotNull: true, Type: &plugin.Identifier{Name: "integer"}}}},
+							Columns: []*plugin.Column{{Name: "id", NotNull: true, Type: &plugin.Identifier{Name: "integer"}}},
+						},
+					},
+				}
+				const mockBaseGo = `
+package sqlc
+
+import (
+	"context"
+	"database/sql"
+)
+
+type DBTX interface {
+	ExecContext(context.Context, string, ...interface{}) (sql.Result, error)
+	QueryContext(context.Context, string, ...interface{}) (*sql.Rows, error)
+}
+
+type Queries struct{}
+
+const insertUser = "INSERT INTO users (id, name) VALUES (?, ?)"
+
+type InsertUserParams struct {
+	ID   interface{}
+	Name interface{}
+}
+
+const insertTag = "INSERT INTO tags (name) VALUES (?)"
+
+const insertUserReturning = "INSERT INTO users (id) VALUES (?) RETURNING id"
+`
+				return Args{req: req}, Expected{
+					fileCount: 1,
+					contains: []string{
+						"func (q *Queries) BulkInsertUserSeq(ctx context.Context, db DBTX, rows iter.Seq[InsertUserParams]) (int64, error) {",
+						"func (q *Queries) BulkInsertUserSeq2(ctx context.Context, db DBTX, rows iter.Seq2[InsertUserParams, error]) (int64, error) {",
+						"chunkSize, err := bulkChunkSize(2, 0, 32766, 100)",
+						"_, err := q.bulkInsertUser(ctx, db, chunk)",
+						"func (q *Queries) BulkInsertTagSeq(ctx context.Context, db DBTX, rows iter.Seq[string]) (int64, error) {",
+						"return q.bulkInsertTag(ctx, db, chunk)",
+						"func bulkInsertSeq[T any](",
+					},
+					// The rows returned by the chunks are not collected
+					notContains: []string{"BulkInsertUserReturningSeq"},
+					mockBaseGo:  mockBaseGo,
+					err:         nil,
+				}
+			},
+		},
+		"valid:emit_bulk_writers": {
+			arrange: func(t *testing.T) (Args, Expected) {
+				req := &plugin.GenerateRequest{
+					SqlcVersion:   "1.0.0",
+					Settings:      &plugin.Settings{Engine: "postgresql"},
+					PluginOptions: []byte(`{"package": "sqlc", "sql_package": "pgx/v5", "emit_bulk_writers": true}`),
+					Queries: []*plugin.Query{
+						{
+							Name: "UpsertUser",
+							Cmd:  ":execresult",
+							Text: "INSERT INTO users (id, name) VALUES ($1, $2) ON CONFLICT (id) DO UPDATE SET updated_by = $3",
+							Params: []*plugin.Parameter{
+								{Number: 1, Column: &plugin.Column{Name: "id"}},
+								{Number: 2, Column: &plugin.Column{Name: "name"}},
+								{Number: 3, Column: &plugin.Column{Name: "updated_by", NotNull: true, Type: &plugin.Identifier{Name: "text"}}},
+							},
+						},
+						{
+							Name:    "InsertUserReturning",
+							Cmd:     ":many",
+							Text:    "INSERT INTO users (id) VALUES ($1) RETURNING id",
+							Params:  []*plugin.Parameter{{Number: 1, Column: &plugin.Column{Name: "id", NotNull: true, Type: &plugin.Identifier{Name: "int8"}}}},
+							Columns: []*plugin.Column{{Name: "id", NotNull: true, Type: &plugin.Identifier{Name: "int8"}}},
+						},
+					},
+				}
+				const mockBaseGo = `
+package sqlc
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type DBTX interface {
+	Exec(context.Context, string, ...interface{}) (pgconn.CommandTag, error)
+	Query(context.Context, string, ...interface{}) (pgx.Rows, error)
+}
+
+type Queries struct {
+	db DBTX
+}
+
+const upsertUser = "INSERT INTO users (id, name) VALUES ($1, $2) ON CONFLICT (id) DO UPDATE SET updated_by = $3"
+
+type UpsertUserParams struct {
+	ID   interface{}
+	Name interface{}
+}
+
+const insertUserReturning = "INSERT INTO users (id) VALUES ($1) RETURNING id"
+`
+				return Args{req: req}, Expected{
+					fileCount: 1,
+					contains: []string{
+						"type UpsertUserBulkWriter struct {\n\twriter *bulkWriter[UpsertUserParams]\n}",
+						"func (q *Queries) NewUpsertUserBulkWriter(size int, stmtUpdatedBy string) (*UpsertUserBulkWriter, error) {",
+						"_, err := q.bulkUpsertUser(ctx, db, rows, stmtUpdatedBy)",
+						"func (w *UpsertUserBulkWriter) Add(ctx context.Context, row UpsertUserParams) error {",
+						"func (w *UpsertUserBulkWriter) Flush(ctx context.Context) error {",
+						"func (w *UpsertUserBulkWriter) Close(ctx context.Context) error {",
+					},
+					// The rows returned by the chunks are not collected
+					notContains: []string{"InsertUserReturningBulkWriter"},
+					mockBaseGo:  mockBaseGo,
+					err:         nil,
+				}
+			},
+		},
+		"valid:emit_async_bulk_writers": {
+			arrange: func(t *testing.T) (Args, Expected) {
+				req := &plugin.GenerateRequest{
+					SqlcVersion:   "1.0.0",
+					Settings:      &plugin.Settings{Engine: "mysql"},
+					PluginOptions: []byte(`{"package": "sqlc", "emit_async_bulk_writers": true, "emit_bulk_writers": true, "emit_methods_with_db_argument": true}`),
+					Queries: []*plugin.Query{
+						{
+							Name: "InsertEvent",
+							Cmd:  ":execrows",
+							Text: "INSERT INTO events (created_at, payload) VALUES (?, ?)",
+							Params: []*plugin.Parameter{
+								{Number: 1, Column: &plugin.Column{Name: "created_at", NotNull: true, Type: &plugin.Identifier{Name: "datetime"}}},
+								{Number: 2, Column: &plugin.Column{Name: "payload"}},
+							},
+						},
+					},
+				}
+				const mockBaseGo = `
+package sqlc
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+type DBTX interface {
+	ExecContext(context.Context, string, ...interface{}) (sql.Result, error)
+}
+
+type Queries struct{}
+
+const insertEvent = "INSERT INTO events (created_at, payload) VALUES (?, ?)"
+
+type InsertEventParams struct {
+	CreatedAt time.Time
+	Payload   interface{}
+}
+`
+				return Args{req: req}, Expected{
+					fileCount: 1,
+					contains: []string{
+						"func (q *Queries) NewInsertEventAsyncBulkWriter(ctx context.Context, db DBTX, size int, interval time.Duration,\n\tonError func(err error, rows []InsertEventParams)) (*InsertEventAsyncBulkWriter, error) {",
+						"_, err := q.bulkInsertEvent(ctx, db, rows)",
+						"writer: newBulkAsyncWriter(ctx, size, interval, size, flush, onError, bulkTicker),",
+						"func (w *InsertEventAsyncBulkWriter) Add(ctx context.Context, row InsertEventParams) error {",
+						"func (w *InsertEventAsyncBulkWriter) Close(ctx context.Context) error {",
+						"func (q *Queries) NewInsertEventBulkWriter(db DBTX, size int) (*InsertEventBulkWriter, error) {",
+						// "time" of the row type and the helpers is imported once
+						"\t\"sync\"\n\t\"time\"\n)",
+					},
+					mockBaseGo: mockBaseGo,
+					err:        nil,
+				}
+			},
+		},
+		"valid:emit_load_data_functions": {
+			arrange: func(t *testing.T) (Args, Expected) {
+				req := &plugin.GenerateRequest{
+					SqlcVersion:   "1.0.0",
+					Settings:      &plugin.Settings{Engine: "mysql"},
+					PluginOptions: []byte(`{"package": "sqlc", "emit_load_data_functions": true}`),
+					Queries: []*plugin.Query{
+						{
+							Name:            "InsertUser",
+							Text:            "INSERT INTO app.users (`Name`, id) VALUES (?, ?)",
+							InsertIntoTable: &plugin.Identifier{Schema: "app", Name: "users"},
+							Params: []*plugin.Parameter{
+								{Number: 1, Column: &plugin.Column{Name: "Name"}},
+								{Number: 2, Column: &plugin.Column{Name: "id"}},
+							},
+						},
+						{
+							Name:            "UpsertUser",
+							Text:            "INSERT INTO users (id, name) VALUES (?, ?) ON DUPLICATE KEY UPDATE name = VALUES(name)",
+							InsertIntoTable: &plugin.Identifier{Name: "users"},
+							Params: []*plugin.Parameter{
+								{Number: 1, Column: &plugin.Column{Name: "id"}},
+								{Number: 2, Column: &plugin.Column{Name: "name"}},
+							},
+						},
+					},
+				}
+				const mockBaseGo = `
+package sqlc
+
+import (
+	"context"
+	"database/sql"
+)
+
+type DBTX interface {
+	ExecContext(context.Context, string, ...interface{}) (sql.Result, error)
+}
+
+type Queries struct {
+	db DBTX
+}
+
+const insertUser = "INSERT INTO app.users (` + "`Name`" + `, id) VALUES (?, ?)"
+
+type InsertUserParams struct {
+	Name interface{}
+	ID   interface{}
+}
+
+const upsertUser = "INSERT INTO users (id, name) VALUES (?, ?) ON DUPLICATE KEY UPDATE name = VALUES(name)"
+
+type UpsertUserParams struct {
+	ID   interface{}
+	Name interface{}
+}
+`
+				return Args{req: req}, Expected{
+					fileCount: 1,
+					contains: []string{
+						"func (q *Queries) BulkInsertUserLoadData(ctx context.Context, args BulkInsertUserParams) (int64, error) {",
+						// The columns are in the order of the fields
+						"values, err := extractFieldValues(args, []string{\"Name\", \"ID\"})",
+						"bulkLoadDataLocalInfile(ctx, q.db, []string{\"app\", \"users\"}, []string{\"Name\", \"id\"}, values, mysql.RegisterReaderHandler, mysql.DeregisterReaderHandler)",
+						"func bulkWriteLoadDataRows(",
+					},
+					// LOAD DATA cannot run an upsert
+					notContains: []string{"BulkUpsertUserLoadData"},
+					mockBaseGo:  mockBaseGo,
+					err:         nil,
+				}
+			},
+		},
+		"valid:batch_size": {
+			arrange: func(t *testing.T) (Args, Expected) {
+				req := &plugin.GenerateRequest{
+					SqlcVersion:   "1.0.0",
+					Settings:      &plugin.Settings{Engine: "sqlite"},
+					PluginOptions: []byte(`{"package": "sqlc", "batch_size": 1000}`),
+					Queries: []*plugin.Query{
+						{
+							Name: "InsertUser",
+							Text: "INSERT INTO users (id, name) VALUES (?, ?)",
+							Params: []*plugin.Parameter{
+								{Number: 1, Column: &plugin.Column{Name: "id"}},
+								{Number: 2, Column: &plugin.Column{Name: "name"}},
+							},
+						},
+					},
+				}
+				return Args{req: req}, Expected{
+					fileCount: 1,
+					contains:  []string{"chunkSize, err := bulkChunkSize(numParamsPerArg, 0, 32766, 1000)"},
+					err:       nil,
+				}
+			},
+		},
+		"valid:max_batch_bytes": {
+			arrange: func(t *testing.T) (Args, Expected) {
+				req := &plugin.GenerateRequest{
+					SqlcVersion:   "1.0.0",
+					Settings:      &plugin.Settings{Engine: "mysql"},
+					PluginOptions: []byte(`{"package": "sqlc", "max_batch_bytes": 16777216}`),
+					Queries: []*plugin.Query{
+						{
+							Name: "InsertUser",
+							Text: "INSERT INTO users (id, name) VALUES (?, ?)",
+							Params: []*plugin.Parameter{
+								{Number: 1, Column: &plugin.Column{Name: "id"}},
+								{Number: 2, Column: &plugin.Column{Name: "name"}},
+							},
+						},
+					},
+				}
+				return Args{req: req}, Expected{
+					fileCount: 1,
+					contains: []string{
+						"range bulkChunkEnds(originalQuery, preparedValues, nil, numParamsPerArg, chunkSize, 16777216) {",
+					},
+					err: nil,
+				}
+			},
+		},
+		"invalid:Parameter not found in the query text": {
 			arrange: func(t *testing.T) (Args, Expected) {
 				req := &plugin.GenerateRequest{
 					SqlcVersion:   "1.0.0",
+					Settings:      &plugin.Settings{Engine: "postgresql"},
 					PluginOptions: []byte(`{"package": "sqlc"}`),
 					Queries: []*plugin.Query{
 						{
 							Name: "InsertUser",
-							Text: "INSERT INTO users (id, name) VALUES (?, ?) ON DUPLICATE KEY UPDATE id = id",
+							Text: "INSERT INTO users (id, name) VALUES ($1, $2)",
 							Params: []*plugin.Parameter{
-								{Column: &plugin.Column{Name: "id"}},
-								{Column: &plugin.Column{Name: "name"}},
+								{Number: 1, Column: &plugin.Column{Name: "id"}},
+								{Number: 2, Column: &plugin.Column{Name: "name"}},
+								{Number: 3, Column: &plugin.Column{Name: "email"}},
 							},
 						},
 					},
 				}
-				return Args{req: req}, Expected{fileCount: 1, err: nil}
+				return Args{req: req}, Expected{err: errors.New("query InsertUser: parameter 3 is not found in the query text")}
 			},
 		},
 		"valid:No INSERT Queries": {
@@ -77,7 +2326,7 @@ func TestGenerate(t *testing.T) {
 							Name: "SelectUser",
 							Text: "SELECT * FROM users WHERE id = ?",
 							Params: []*plugin.Parameter{
-								{Column: &plugin.Column{Name: "id"}},
+								{Number: 1, Column: &plugin.Column{Name: "id"}},
 							},
 						},
 						{
@@ -100,6 +2349,111 @@ func TestGenerate(t *testing.T) {
 				return Args{req: req}, Expected{err: errors.New(`"package" is required`)}
 			},
 		},
+		"invalid:Negative query_parameter_limit": {
+			arrange: func(t *testing.T) (Args, Expected) {
+				req := &plugin.GenerateRequest{
+					SqlcVersion:   "1.0.0",
+					PluginOptions: []byte(`{"package": "sqlc", "query_parameter_limit": -1}`),
+					Queries:       []*plugin.Query{},
+				}
+				return Args{req: req}, Expected{err: errors.New(`"query_parameter_limit" must not be negative`)}
+			},
+		},
+		"invalid:Unknown sql_package": {
+			arrange: func(t *testing.T) (Args, Expected) {
+				req := &plugin.GenerateRequest{
+					SqlcVersion:   "1.0.0",
+					PluginOptions: []byte(`{"package": "sqlc", "sql_package": "pgx/v6"}`),
+					Queries:       []*plugin.Query{},
+				}
+				return Args{req: req}, Expected{err: errors.New(`unknown "sql_package": pgx/v6`)}
+			},
+		},
+		"invalid:Negative batch_size": {
+			arrange: func(t *testing.T) (Args, Expected) {
+				req := &plugin.GenerateRequest{
+					SqlcVersion:   "1.0.0",
+					PluginOptions: []byte(`{"package": "sqlc", "batch_size": -1}`),
+					Queries:       []*plugin.Query{},
+				}
+				return Args{req: req}, Expected{err: errors.New(`"batch_size" must not be negative`)}
+			},
+		},
+		"invalid:Negative max_batch_bytes": {
+			arrange: func(t *testing.T) (Args, Expected) {
+				req := &plugin.GenerateRequest{
+					SqlcVersion:   "1.0.0",
+					PluginOptions: []byte(`{"package": "sqlc", "max_batch_bytes": -1}`),
+					Queries:       []*plugin.Query{},
+				}
+				return Args{req: req}, Expected{err: errors.New(`"max_batch_bytes" must not be negative`)}
+			},
+		},
+		"invalid:preserve_returning_order on MySQL": {
+			arrange: func(t *testing.T) (Args, Expected) {
+				req := &plugin.GenerateRequest{
+					SqlcVersion:   "1.0.0",
+					Settings:      &plugin.Settings{Engine: "mysql"},
+					PluginOptions: []byte(`{"package": "sqlc", "preserve_returning_order": true}`),
+					Queries:       []*plugin.Query{},
+				}
+				return Args{req: req}, Expected{
+					err: errors.New(`"preserve_returning_order" is supported only by the postgresql engine`),
+				}
+			},
+		},
+		"invalid:Unknown bulk_mode": {
+			arrange: func(t *testing.T) (Args, Expected) {
+				req := &plugin.GenerateRequest{
+					SqlcVersion:   "1.0.0",
+					PluginOptions: []byte(`{"package": "sqlc", "bulk_mode": "copy"}`),
+					Queries:       []*plugin.Query{},
+				}
+				return Args{req: req}, Expected{err: errors.New(`unknown "bulk_mode": copy`)}
+			},
+		},
+		"invalid:bulk_mode unnest on SQLite": {
+			arrange: func(t *testing.T) (Args, Expected) {
+				req := &plugin.GenerateRequest{
+					SqlcVersion:   "1.0.0",
+					Settings:      &plugin.Settings{Engine: "sqlite"},
+					PluginOptions: []byte(`{"package": "sqlc", "bulk_mode": "unnest"}`),
+					Queries:       []*plugin.Query{},
+				}
+				return Args{req: req}, Expected{err: errors.New(`"bulk_mode" "unnest" is supported only by the postgresql engine`)}
+			},
+		},
+		"invalid:emit_copy_functions without pgx": {
+			arrange: func(t *testing.T) (Args, Expected) {
+				req := &plugin.GenerateRequest{
+					SqlcVersion:   "1.0.0",
+					PluginOptions: []byte(`{"package": "sqlc", "emit_copy_functions": true}`),
+					Queries:       []*plugin.Query{},
+				}
+				return Args{req: req}, Expected{err: errors.New(`"emit_copy_functions" requires "sql_package" pgx/v4 or pgx/v5`)}
+			},
+		},
+		"invalid:emit_load_data_functions on postgresql": {
+			arrange: func(t *testing.T) (Args, Expected) {
+				req := &plugin.GenerateRequest{
+					SqlcVersion:   "1.0.0",
+					Settings:      &plugin.Settings{Engine: "postgresql"},
+					PluginOptions: []byte(`{"package": "sqlc", "emit_load_data_functions": true}`),
+					Queries:       []*plugin.Query{},
+				}
+				return Args{req: req}, Expected{err: errors.New(`"emit_load_data_functions" is supported only by the mysql engine`)}
+			},
+		},
+		"invalid:emit_batch_functions without pgx": {
+			arrange: func(t *testing.T) (Args, Expected) {
+				req := &plugin.GenerateRequest{
+					SqlcVersion:   "1.0.0",
+					PluginOptions: []byte(`{"package": "sqlc", "emit_batch_functions": true}`),
+					Queries:       []*plugin.Query{},
+				}
+				return Args{req: req}, Expected{err: errors.New(`"emit_batch_functions" requires "sql_package" pgx/v4 or pgx/v5`)}
+			},
+		},
 		"invalid:Options parse error": {
 			arrange: func(t *testing.T) (Args, Expected) {
 				req := &plugin.GenerateRequest{
@@ -124,10 +2478,34 @@ func TestGenerate(t *testing.T) {
 			}
 			assert.NilError(t, err)
 			assert.Equal(t, len(got.Files), want.fileCount, "Generated file count mismatch")
+			for _, c := range want.contains {
+				assert.Assert(t, strings.Contains(string(got.Files[0].Contents), c),
+					"generated code does not contain %q", c)
+			}
+			for _, c := range want.notContains {
+				assert.Assert(t, !strings.Contains(string(got.Files[0].Contents), c),
+					"generated code contains %q", c)
+			}
+
+			mockBaseGo := want.mockBaseGo
+			if mockBaseGo == "" {
+				mockBaseGo = defaultMockBaseGo
+			}
+			// Combine mock files and generated files into slices
+			mockFile := &plugin.File{
+				Name:     "mock_base.go",
+				Contents: []byte(mockBaseGo),
+			}
+			allFiles := append(got.Files, mockFile)
+
+			assertGeneratedCodeIsValid(t, allFiles)
+		})
+	}
+}
 
-			// To perform type checking with assertGeneratedCodeIsValid,
-			// we prepare a minimal mock of the code sqlc-gen-go is generate.
-			const mockBaseGo = `
+// To perform type checking with assertGeneratedCodeIsValid,
+// we prepare a minimal mock of the code sqlc-gen-go is generate.
+const defaultMockBaseGo = `
 package sqlc
 
 import (
@@ -150,17 +2528,6 @@ type InsertUserParams struct {
 	Name any
 }
 `
-			// Combine mock files and generated files into slices
-			mockFile := &plugin.File{
-				Name:     "mock_base.go",
-				Contents: []byte(mockBaseGo),
-			}
-			allFiles := append(got.Files, mockFile)
-
-			assertGeneratedCodeIsValid(t, allFiles)
-		})
-	}
-}
 
 func assertGeneratedCodeIsValid(t *testing.T, files []*plugin.File) {
 	t.Helper()
@@ -183,7 +2550,7 @@ func assertGeneratedCodeIsValid(t *testing.T, files []*plugin.File) {
 		return
 	}
 	conf := types.Config{
-		Importer: importer.Default(),
+		Importer: &fakeImporter{fallback: importer.Default()},
 		Error: func(err error) {
 			t.Fatalf("Failed to type check generated code: %v", err)
 		},
@@ -194,3 +2561,174 @@ func assertGeneratedCodeIsValid(t *testing.T, files []*plugin.File) {
 		t.Fatalf("Type checking failed for generated code: %v", err)
 	}
 }
+
+// fakePackages are minimal stubs of the third-party packages that the generated code and the mocks import,
+// so that assertGeneratedCodeIsValid can type check them without the real modules.
+var fakePackages = map[string]string{
+	"github.com/jackc/pgx/v5": `
+package pgx
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type Tx interface {
+	Begin(ctx context.Context) (Tx, error)
+	Commit(ctx context.Context) error
+	Rollback(ctx context.Context) error
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (Rows, error)
+}
+
+type Identifier []string
+
+type CopyFromSource interface {
+	Next() bool
+	Values() ([]any, error)
+	Err() error
+}
+
+type Rows interface {
+	Close()
+	Err() error
+	Next() bool
+	Scan(dest ...any) error
+}
+
+type Batch struct{}
+
+func (b *Batch) Queue(query string, arguments ...any) {}
+
+type BatchResults interface {
+	Exec() (pgconn.CommandTag, error)
+	Close() error
+}
+`,
+	"github.com/jackc/pgx/v5/pgconn": `
+package pgconn
+
+type CommandTag struct{}
+
+func (ct CommandTag) RowsAffected() int64 { return 0 }
+`,
+	"github.com/jackc/pgx/v4": `
+package pgx
+
+import (
+	"context"
+
+	"github.com/jackc/pgconn"
+)
+
+type Tx interface {
+	Begin(ctx context.Context) (Tx, error)
+	Commit(ctx context.Context) error
+	Rollback(ctx context.Context) error
+	Exec(ctx context.Context, sql string, arguments ...interface{}) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...interface{}) (Rows, error)
+}
+
+type Identifier []string
+
+type CopyFromSource interface {
+	Next() bool
+	Values() ([]interface{}, error)
+	Err() error
+}
+
+type Rows interface {
+	Close()
+	Err() error
+	Next() bool
+	Scan(dest ...interface{}) error
+}
+
+type Batch struct{}
+
+func (b *Batch) Queue(query string, arguments ...interface{}) {}
+
+type BatchResults interface {
+	Exec() (pgconn.CommandTag, error)
+	Close() error
+}
+`,
+	"github.com/jackc/pgconn": `
+package pgconn
+
+type CommandTag []byte
+
+func (ct CommandTag) RowsAffected() int64 { return 0 }
+`,
+	"github.com/google/uuid": `
+package uuid
+
+type UUID [16]byte
+`,
+	"github.com/go-sql-driver/mysql": `
+package mysql
+
+import "io"
+
+func RegisterReaderHandler(name string, handler func() io.Reader) {}
+
+func DeregisterReaderHandler(name string) {}
+`,
+	"github.com/lib/pq": `
+package pq
+
+import "database/sql/driver"
+
+func Array(a any) interface {
+	driver.Valuer
+	Scan(src any) error
+} {
+	return nil
+}
+`,
+	"github.com/jackc/pgx/v5/pgtype": `
+package pgtype
+
+type Text struct {
+	String string
+	Valid  bool
+}
+
+type Timestamptz struct {
+	Valid bool
+}
+`,
+}
+
+// fakeImporter imports fakePackages from their stubs and the other packages with fallback.
+// A package is type checked only once, so that all importers share the same types.
+type fakeImporter struct {
+	fallback types.Importer
+	packages map[string]*types.Package
+}
+
+func (i *fakeImporter) Import(path string) (*types.Package, error) {
+	src, ok := fakePackages[path]
+	if !ok {
+		return i.fallback.Import(path)
+	}
+	if pkg, ok := i.packages[path]; ok {
+		return pkg, nil
+	}
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path+".go", src, 0)
+	if err != nil {
+		return nil, err
+	}
+	conf := types.Config{Importer: i}
+	pkg, err := conf.Check(path, fset, []*ast.File{file}, nil)
+	if err != nil {
+		return nil, err
+	}
+	if i.packages == nil {
+		i.packages = make(map[string]*types.Package)
+	}
+	i.packages[path] = pkg
+	return pkg, nil
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/sqlc-dev/plugin-sdk-go/sdk"
+)
+
+// structName converts a column, table or enum name to a Go identifier in the same way as StructName of
+// sqlc-gen-go (internal/codegen/golang/struct.go), which names the fields of the structs it generates.
+//   - A name in the "rename" option is replaced as it is: {"user_id": "UserIdentifier"}
+//   - Characters other than letters and digits are treated as word separators: "user-name" -> "UserName"
+//   - Each word is title-cased without changing the rest of it: "userId" -> "UserId", "ID" -> "ID"
+//   - Words in the "initialisms" option (default: "id") are matched case-sensitively and upper-cased:
+//     "user_id" -> "UserID", "user_Id" -> "UserId"
+//   - A name starting with a digit is prefixed with "_": "2fa_code" -> "_2faCode"
+func structName(name string, opts *Options) string {
+	if rename := opts.Rename[name]; rename != "" {
+		return rename
+	}
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return '_'
+	}, name)
+
+	var out strings.Builder
+	for _, p := range strings.Split(name, "_") {
+		if _, found := opts.InitialismsMap[p]; found {
+			out.WriteString(strings.ToUpper(p))
+		} else {
+			// sdk.Title is strings.Title, which sqlc-gen-go uses
+			out.WriteString(sdk.Title(p))
+		}
+	}
+
+	// If a name has a digit as its first char, prepend an underscore to make it a valid Go name.
+	if r, _ := utf8.DecodeRuneInString(out.String()); unicode.IsDigit(r) {
+		return "_" + out.String()
+	}
+	return out.String()
+}
+
+// statementArgName converts a column name to the name of the argument of a statement-level parameter:
+// "updated_by" -> "stmtUpdatedBy", "updated-by" -> "stmtUpdatedBy".
+// The name is converted with structName, and the fixed prefix keeps it from shadowing the builtins,
+// the imported packages and the variables and helpers of the generated code.
+func statementArgName(name string, opts *Options) string {
+	return "stmt" + structName(name, opts)
+}
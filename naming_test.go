@@ -0,0 +1,256 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/sqlc-dev/plugin-sdk-go/plugin"
+	"gotest.tools/v3/assert"
+)
+
+// Test_structName checks parity with StructName of sqlc-gen-go using its default initialisms.
+func Test_structName(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		input string
+		want  string
+	}{
+		"empty": {
+			input: "",
+			want:  "",
+		},
+		"simple": {
+			input: "name",
+			want:  "Name",
+		},
+		"standard snake case": {
+			input: "user_name",
+			want:  "UserName",
+		},
+		"common initialism (id)": {
+			input: "user_id",
+			want:  "UserID",
+		},
+		"common initialism (id) at start": {
+			input: "id_value",
+			want:  "IDValue",
+		},
+		"only id is an initialism by default (url)": {
+			input: "profile_url",
+			want:  "ProfileUrl",
+		},
+		"only id is an initialism by default (json)": {
+			input: "json_data",
+			want:  "JsonData",
+		},
+		"double underscore": {
+			input: "user__id",
+			want:  "UserID",
+		},
+		"leading underscore": {
+			input: "_user_name",
+			want:  "UserName",
+		},
+		"trailing underscore": {
+			input: "user_name_",
+			want:  "UserName",
+		},
+		"only underscores": {
+			input: "__",
+			want:  "",
+		},
+		"camel case is kept": {
+			input: "userId",
+			want:  "UserId",
+		},
+		"pascal case is kept": {
+			input: "UserID",
+			want:  "UserID",
+		},
+		"upper case initialism": {
+			input: "ID",
+			want:  "ID",
+		},
+		"initialisms are case-sensitive": {
+			input: "user_Id",
+			want:  "UserId",
+		},
+		"mixed case words": {
+			input: "snake_Case_Mixed",
+			want:  "SnakeCaseMixed",
+		},
+		"hyphen": {
+			input: "user-name",
+			want:  "UserName",
+		},
+		"space": {
+			input: "first name",
+			want:  "FirstName",
+		},
+		"dot": {
+			input: "user.name",
+			want:  "UserName",
+		},
+		"dollar": {
+			input: "$1",
+			want:  "_1",
+		},
+		"digit suffix": {
+			input: "address_2",
+			want:  "Address2",
+		},
+		"initialism with digit suffix": {
+			input: "id_2",
+			want:  "ID2",
+		},
+		"digits inside a word": {
+			input: "utf8_text",
+			want:  "Utf8Text",
+		},
+		"only the first letter is title-cased": {
+			input: "a1b2",
+			want:  "A1b2",
+		},
+		"leading digit": {
+			input: "2fa_code",
+			want:  "_2faCode",
+		},
+		"only digits": {
+			input: "123",
+			want:  "_123",
+		},
+		"initialism prefix of a word": {
+			input: "idx_id",
+			want:  "IdxID",
+		},
+		"plural of an initialism": {
+			input: "ids",
+			want:  "Ids",
+		},
+		"unicode letters": {
+			input: "café_au_lait",
+			want:  "CaféAuLait",
+		},
+		"unicode title case": {
+			input: "ǆemal",
+			want:  "ǅemal",
+		},
+		"sqlc parameter without a column name": {
+			input: "column_1",
+			want:  "Column1",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			got := structName(tt.input, newTestOptions(t, `{"package": "db"}`))
+			assert.Equal(t, got, tt.want)
+		})
+	}
+}
+
+func Test_structName_options(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		pluginOptions string
+		globalOptions string
+		input         string
+		want          string
+	}{
+		"rename": {
+			pluginOptions: `{"package": "db", "rename": {"user_id": "UserIdentifier"}}`,
+			input:         "user_id",
+			want:          "UserIdentifier",
+		},
+		"rename is matched with the original name": {
+			pluginOptions: `{"package": "db", "rename": {"UserID": "UserIdentifier"}}`,
+			input:         "user_id",
+			want:          "UserID",
+		},
+		"global rename": {
+			pluginOptions: `{"package": "db"}`,
+			globalOptions: `{"rename": {"user_id": "UID"}}`,
+			input:         "user_id",
+			want:          "UID",
+		},
+		"global rename takes precedence": {
+			pluginOptions: `{"package": "db", "rename": {"user_id": "UserIdentifier"}}`,
+			globalOptions: `{"rename": {"user_id": "UID"}}`,
+			input:         "user_id",
+			want:          "UID",
+		},
+		"custom initialisms": {
+			pluginOptions: `{"package": "db", "initialisms": ["id", "url", "json"]}`,
+			input:         "profile_url_json_id",
+			want:          "ProfileURLJSONID",
+		},
+		"custom initialisms replace the default": {
+			pluginOptions: `{"package": "db", "initialisms": ["url"]}`,
+			input:         "user_id",
+			want:          "UserId",
+		},
+		"no initialisms": {
+			pluginOptions: `{"package": "db", "initialisms": []}`,
+			input:         "user_id",
+			want:          "UserId",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			opts, err := ParseOptions(&plugin.GenerateRequest{
+				PluginOptions: []byte(tt.pluginOptions),
+				GlobalOptions: []byte(tt.globalOptions),
+			})
+			assert.NilError(t, err)
+			assert.Equal(t, structName(tt.input, opts), tt.want)
+		})
+	}
+}
+
+func Test_statementArgName(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		input string
+		want  string
+	}{
+		"snake case": {
+			input: "updated_by",
+			want:  "stmtUpdatedBy",
+		},
+		"id": {
+			input: "author_id",
+			want:  "stmtAuthorID",
+		},
+		"hyphen": {
+			input: "updated-by",
+			want:  "stmtUpdatedBy",
+		},
+		"builtin": {
+			input: "min",
+			want:  "stmtMin",
+		},
+		"keyword": {
+			input: "type",
+			want:  "stmtType",
+		},
+		"leading digit": {
+			input: "2fa_code",
+			want:  "stmt_2faCode",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			got := statementArgName(tt.input, newTestOptions(t, `{"package": "db"}`))
+			assert.Equal(t, got, tt.want)
+		})
+	}
+}
+
+// newTestOptions parses plugin options as Generate does.
+func newTestOptions(t *testing.T, pluginOptions string) *Options {
+	t.Helper()
+	opts, err := ParseOptions(&plugin.GenerateRequest{PluginOptions: []byte(pluginOptions)})
+	assert.NilError(t, err)
+	return opts
+}
@@ -3,12 +3,91 @@ package main
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
+	"maps"
 
 	"github.com/sqlc-dev/plugin-sdk-go/plugin"
 )
 
+const (
+	sqlPackageStandard = "database/sql"
+	sqlPackagePgxV4    = "pgx/v4"
+	sqlPackagePgxV5    = "pgx/v5"
+
+	bulkModeValues = "values"
+	bulkModeUnnest = "unnest"
+)
+
 type Options struct {
 	Package string `json:"package"`
+	// Rename and Initialisms mirror the options of the same name of sqlc-gen-go,
+	// so that the field names match the structs sqlc generates.
+	Rename      map[string]string `json:"rename,omitempty"`
+	Initialisms *[]string         `json:"initialisms,omitempty"`
+	// Overrides mirrors overrides of sqlc-gen-go, which replaces the Go types of columns,
+	// so that the types of the parameters match the ones sqlc generates.
+	Overrides []Override `json:"overrides,omitempty"`
+	// EmitPointersForNullTypes mirrors emit_pointers_for_null_types of sqlc-gen-go,
+	// which uses pointers for the nullable columns with pgx and SQLite.
+	EmitPointersForNullTypes bool `json:"emit_pointers_for_null_types,omitempty"`
+	// QueryParameterLimit mirrors query_parameter_limit of sqlc-gen-go,
+	// which decides whether sqlc generates an XxxParams struct for a query.
+	QueryParameterLimit *int32 `json:"query_parameter_limit,omitempty"`
+	// EmitExportedQueries mirrors emit_exported_queries of sqlc-gen-go,
+	// which exports the query string constants (e.g., CreateUser instead of createUser).
+	EmitExportedQueries bool `json:"emit_exported_queries,omitempty"`
+	// EmitMethodsWithDBArgument mirrors emit_methods_with_db_argument of sqlc-gen-go,
+	// where Queries has no db field and every method takes a DBTX argument.
+	EmitMethodsWithDBArgument bool `json:"emit_methods_with_db_argument,omitempty"`
+	// EmitParamsStructPointers mirrors emit_params_struct_pointers of sqlc-gen-go,
+	// which passes the XxxParams struct by pointer.
+	EmitParamsStructPointers bool `json:"emit_params_struct_pointers,omitempty"`
+	// EmitExactTableNames and InflectionExcludeTableNames mirror the options of the same name of sqlc-gen-go,
+	// so that the names of the model structs returned by RETURNING queries match.
+	EmitExactTableNames         bool     `json:"emit_exact_table_names,omitempty"`
+	InflectionExcludeTableNames []string `json:"inflection_exclude_table_names,omitempty"`
+	// SqlPackage mirrors sql_package of sqlc-gen-go: "database/sql" (default), "pgx/v4" or "pgx/v5".
+	// With pgx, DBTX has Exec instead of ExecContext and the parameters have pgx types.
+	SqlPackage string `json:"sql_package,omitempty"`
+	// BatchSize is the maximum number of rows of a bulk insert statement.
+	// Larger inputs are split into chunks that are executed in turn; 0 means no limit other than the placeholder limit.
+	BatchSize int `json:"batch_size,omitempty"`
+	// MaxBatchBytes is the budget of the estimated size of a bulk insert statement in bytes,
+	// such as a value below max_allowed_packet of MySQL. 0 disables the size-based splitting.
+	MaxBatchBytes int `json:"max_batch_bytes,omitempty"`
+	// BulkMode is how the rows are bound to a bulk insert statement: "values" (default) expands the VALUES clause
+	// per row, and "unnest" (PostgreSQL only) binds one array per column so that the statement has a fixed text.
+	BulkMode string `json:"bulk_mode,omitempty"`
+	// EmitCopyFunctions generates BulkXxxCopy functions that insert the rows with pgx CopyFrom
+	// for the queries that COPY can run. It requires a pgx sql_package.
+	EmitCopyFunctions bool `json:"emit_copy_functions,omitempty"`
+	// EmitSeqFunctions generates BulkXxxSeq and BulkXxxSeq2 functions that insert the rows pulled from an iterator.
+	EmitSeqFunctions bool `json:"emit_seq_functions,omitempty"`
+	// EmitBulkWriters generates XxxBulkWriter types that buffer rows and insert them in bulk.
+	EmitBulkWriters bool `json:"emit_bulk_writers,omitempty"`
+	// EmitAsyncBulkWriters generates XxxAsyncBulkWriter types that insert the added rows in bulk
+	// from a background goroutine when enough rows are buffered or an interval passes.
+	EmitAsyncBulkWriters bool `json:"emit_async_bulk_writers,omitempty"`
+	// EmitLoadDataFunctions generates BulkXxxLoadData functions that stream the rows to LOAD DATA LOCAL INFILE.
+	// It is supported only by the mysql engine.
+	EmitLoadDataFunctions bool `json:"emit_load_data_functions,omitempty"`
+	// EmitBatchFunctions generates BulkXxxBatch functions that queue the original statement once per row
+	// in a pgx.Batch. It requires a pgx sql_package.
+	EmitBatchFunctions bool `json:"emit_batch_functions,omitempty"`
+	// PreserveReturningOrder makes the rows returned by RETURNING queries line up with the input rows.
+	// Each row is inserted by its own data-modifying CTE that returns the index of the row. PostgreSQL only.
+	PreserveReturningOrder bool `json:"preserve_returning_order,omitempty"`
+	// SkipAutoIncrementCheck skips reading innodb_autoinc_lock_mode and auto_increment_increment
+	// before the bulk inserts of MySQL :execlastid queries; the IDs are then assumed to have a step of 1.
+	SkipAutoIncrementCheck bool `json:"skip_auto_increment_check,omitempty"`
+
+	InitialismsMap map[string]struct{} `json:"-"`
+}
+
+// GlobalOptions are the options shared by all codegen plugins (the top-level "options" of sqlc.yaml).
+type GlobalOptions struct {
+	Overrides []Override        `json:"overrides,omitempty"`
+	Rename    map[string]string `json:"rename,omitempty"`
 }
 
 func ParseOptions(req *plugin.GenerateRequest) (*Options, error) {
@@ -16,6 +95,44 @@ func ParseOptions(req *plugin.GenerateRequest) (*Options, error) {
 	if err := json.Unmarshal(req.GetPluginOptions(), &options); err != nil {
 		return nil, err
 	}
+
+	// As sqlc-gen-go does, the global overrides are matched before the plugin ones
+	// and the global rename entries take precedence over the plugin ones
+	if len(req.GetGlobalOptions()) > 0 {
+		var global GlobalOptions
+		if err := json.Unmarshal(req.GetGlobalOptions(), &global); err != nil {
+			return nil, err
+		}
+		if len(global.Overrides) > 0 {
+			options.Overrides = append(global.Overrides, options.Overrides...)
+		}
+		if len(global.Rename) > 0 && options.Rename == nil {
+			options.Rename = make(map[string]string, len(global.Rename))
+		}
+		maps.Copy(options.Rename, global.Rename)
+	}
+
+	defaultSchema := "public"
+	if req.GetCatalog() != nil {
+		defaultSchema = req.GetCatalog().GetDefaultSchema()
+	}
+	for i := range options.Overrides {
+		if err := options.Overrides[i].parse(defaultSchema); err != nil {
+			return nil, fmt.Errorf("options: %w", err)
+		}
+	}
+
+	if options.QueryParameterLimit == nil {
+		options.QueryParameterLimit = new(int32)
+		*options.QueryParameterLimit = 1
+	}
+	if options.Initialisms == nil {
+		options.Initialisms = &[]string{"id"}
+	}
+	options.InitialismsMap = make(map[string]struct{}, len(*options.Initialisms))
+	for _, initialism := range *options.Initialisms {
+		options.InitialismsMap[initialism] = struct{}{}
+	}
 	return &options, nil
 }
 
@@ -23,5 +140,35 @@ func ValidateOptions(opts *Options) error {
 	if opts.Package == "" {
 		return errors.New(`options: "package" is required`)
 	}
+	switch opts.SqlPackage {
+	case "", sqlPackageStandard, sqlPackagePgxV4, sqlPackagePgxV5:
+	default:
+		return fmt.Errorf(`options: unknown "sql_package": %s`, opts.SqlPackage)
+	}
+	switch opts.BulkMode {
+	case "", bulkModeValues, bulkModeUnnest:
+	default:
+		return fmt.Errorf(`options: unknown "bulk_mode": %s`, opts.BulkMode)
+	}
+	if opts.EmitCopyFunctions && !opts.usesPgx() {
+		return errors.New(`options: "emit_copy_functions" requires "sql_package" pgx/v4 or pgx/v5`)
+	}
+	if opts.EmitBatchFunctions && !opts.usesPgx() {
+		return errors.New(`options: "emit_batch_functions" requires "sql_package" pgx/v4 or pgx/v5`)
+	}
+	if opts.BatchSize < 0 {
+		return errors.New(`options: "batch_size" must not be negative`)
+	}
+	if opts.MaxBatchBytes < 0 {
+		return errors.New(`options: "max_batch_bytes" must not be negative`)
+	}
+	if opts.QueryParameterLimit != nil && *opts.QueryParameterLimit < 0 {
+		return errors.New(`options: "query_parameter_limit" must not be negative`)
+	}
 	return nil
 }
+
+// usesPgx reports whether the generated code uses pgx instead of database/sql.
+func (o *Options) usesPgx() bool {
+	return o.SqlPackage == sqlPackagePgxV4 || o.SqlPackage == sqlPackagePgxV5
+}
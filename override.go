@@ -0,0 +1,269 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/types"
+	"regexp"
+	"strings"
+
+	"github.com/sqlc-dev/plugin-sdk-go/plugin"
+	"github.com/sqlc-dev/plugin-sdk-go/sdk"
+)
+
+// Override mirrors an entry of the "overrides" option of sqlc-gen-go (internal/codegen/golang/opts/override.go),
+// which replaces the Go type of the columns of a database type or of specific columns.
+type Override struct {
+	// GoType is the Go type to use, e.g., "github.com/google/uuid.UUID"
+	GoType GoType `json:"go_type"`
+	// DBType is the database type to override, e.g., "uuid"
+	DBType string `json:"db_type"`
+	// PostgresType is the deprecated name of DBType
+	PostgresType string `json:"postgres_type"`
+	// Nullable and Unsigned make a DBType override match the nullable or unsigned columns of the type
+	Nullable bool `json:"nullable"`
+	Unsigned bool `json:"unsigned"`
+	// Null is the deprecated name of Nullable
+	Null bool `json:"null"`
+	// Column is the column to override, "[catalog.][schema.]table.column", where "*" and "?" are wildcards
+	Column string `json:"column"`
+
+	// The parsed GoType and Column
+	goTypeName   string
+	goImportPath string
+	goPackage    string
+	goBasicType  bool
+	columnName   *regexp.Regexp
+	tableCatalog *regexp.Regexp
+	tableSchema  *regexp.Regexp
+	tableRel     *regexp.Regexp
+}
+
+// GoType mirrors the "go_type" of an override of sqlc-gen-go,
+// which is either a string such as "github.com/google/uuid.UUID" or an object of the fields below.
+type GoType struct {
+	Path    string `json:"import"`
+	Package string `json:"package"`
+	Name    string `json:"type"`
+	Pointer bool   `json:"pointer"`
+	Slice   bool   `json:"slice"`
+	Spec    string `json:"-"`
+}
+
+func (t *GoType) UnmarshalJSON(data []byte) error {
+	var spec string
+	if err := json.Unmarshal(data, &spec); err == nil {
+		*t = GoType{Spec: spec}
+		return nil
+	}
+	type alias GoType
+	var a alias
+	if err := json.Unmarshal(data, &a); err != nil {
+		return err
+	}
+	*t = GoType(a)
+	return nil
+}
+
+// parse validates an override and parses its GoType and Column as sqlc-gen-go does.
+// A Column of "table.column" is in defaultSchema.
+func (o *Override) parse(defaultSchema string) error {
+	if o.PostgresType != "" {
+		if o.DBType != "" {
+			return fmt.Errorf(`override cannot have "db_type" and "postgres_type" together`)
+		}
+		o.DBType = o.PostgresType
+	}
+	if o.Null {
+		o.Nullable = true
+	}
+	switch {
+	case o.Column != "" && o.DBType != "":
+		return fmt.Errorf("override specifying both `column` (%q) and `db_type` (%q) is not valid", o.Column, o.DBType)
+	case o.Column == "" && o.DBType == "":
+		return fmt.Errorf("override must specify one of either `column` or `db_type`")
+	}
+
+	if o.Column != "" {
+		parts := strings.Split(o.Column, ".")
+		if len(parts) < 2 || len(parts) > 4 {
+			return fmt.Errorf("override `column` specifier %q is not the proper format, "+
+				"expected '[catalog.][schema.]tablename.colname'", o.Column)
+		}
+		if len(parts) == 2 {
+			parts = []string{defaultSchema, parts[0], parts[1]}
+		}
+		patterns := []**regexp.Regexp{&o.tableSchema, &o.tableRel, &o.columnName}
+		if len(parts) == 4 {
+			patterns = append([]**regexp.Regexp{&o.tableCatalog}, patterns...)
+		}
+		for i, part := range parts {
+			re, err := compilePattern(part)
+			if err != nil {
+				return err
+			}
+			*patterns[i] = re
+		}
+	}
+	return o.parseGoType()
+}
+
+// parseGoType sets the Go type name, its import path and package alias of an override as sqlc-gen-go does.
+func (o *Override) parseGoType() error {
+	gt := o.GoType
+	if gt.Spec == "" {
+		if gt.Path == "" && gt.Package != "" {
+			return fmt.Errorf("package override `go_type`: package name requires an import path")
+		}
+		pkg := gt.Package
+		if pkg == "" && gt.Path != "" {
+			var needsAlias bool
+			if pkg, needsAlias = packageID(gt.Path); needsAlias {
+				o.goPackage = pkg
+			}
+		} else {
+			o.goPackage = gt.Package
+		}
+		o.goImportPath = gt.Path
+		o.goTypeName = gt.Name
+		o.goBasicType = gt.Path == "" && gt.Package == ""
+		if pkg != "" {
+			o.goTypeName = pkg + "." + o.goTypeName
+		}
+		if gt.Pointer {
+			o.goTypeName = "*" + o.goTypeName
+		}
+		if gt.Slice {
+			o.goTypeName = "[]" + o.goTypeName
+		}
+		return nil
+	}
+
+	spec := gt.Spec
+	lastDot := strings.LastIndex(spec, ".")
+	lastSlash := strings.LastIndex(spec, "/")
+	typeName := spec
+	if lastDot == -1 && lastSlash == -1 {
+		// A type without a package must be a basic Go type
+		if !isBasicType(typeName) {
+			return fmt.Errorf("package override `go_type` specifier %q is not a Go basic type e.g. 'string'", spec)
+		}
+		o.goBasicType = true
+	} else {
+		if lastDot == -1 {
+			return fmt.Errorf("package override `go_type` specifier %q is not the proper format, "+
+				"expected 'package.type', e.g. 'github.com/segmentio/ksuid.KSUID'", spec)
+		}
+		typeName = strings.TrimSuffix(strings.TrimPrefix(spec[lastSlash+1:], "go-"), "-go")
+		o.goImportPath = spec[:lastDot]
+	}
+	o.goTypeName = typeName
+	if strings.HasPrefix(spec, "*") {
+		o.goImportPath = strings.TrimPrefix(o.goImportPath, "*")
+		o.goTypeName = "*" + o.goTypeName
+	}
+	return nil
+}
+
+var (
+	validPackageID   = regexp.MustCompile(`^[a-zA-Z0-9_]+$`)
+	versionNumber    = regexp.MustCompile(`^v[0-9]+$`)
+	invalidPackageID = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+)
+
+// packageID returns the package name that sqlc-gen-go assumes for an import path,
+// and whether the import needs the name as an alias.
+func packageID(importPath string) (string, bool) {
+	parts := strings.Split(importPath, "/")
+	name := parts[len(parts)-1]
+	if versionNumber.MatchString(name) && len(parts) >= 2 {
+		return invalidPackageID.ReplaceAllString(strings.ToLower(parts[len(parts)-2]), "_"), true
+	}
+	if validPackageID.MatchString(name) {
+		return name, false
+	}
+	return invalidPackageID.ReplaceAllString(strings.ToLower(name), "_"), true
+}
+
+// isBasicType reports whether name is a typed basic Go type such as "string" or "int64".
+func isBasicType(name string) bool {
+	for _, typ := range types.Typ {
+		if info := typ.Info(); info != 0 && info&types.IsUntyped == 0 && typ.Name() == name {
+			return true
+		}
+	}
+	return false
+}
+
+// compilePattern compiles a pattern of an override column, where "*" and "?" are wildcards
+// and "\" escapes them, as the pattern package of sqlc does.
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	var re strings.Builder
+	escaped := false
+	for _, r := range pattern {
+		switch {
+		case escaped:
+			escaped = false
+			if r != '*' && r != '?' && r != '\\' {
+				return nil, fmt.Errorf("invalid escaped character '%c'", r)
+			}
+			re.WriteString(regexp.QuoteMeta(string(r)))
+		case r == '\\':
+			escaped = true
+		case r == '*':
+			re.WriteString(".*")
+		case r == '?':
+			re.WriteString(".")
+		default:
+			re.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	if escaped {
+		return nil, fmt.Errorf("unterminated escape at end of pattern")
+	}
+	return regexp.Compile("^" + re.String() + "$")
+}
+
+// matchesTable reports whether the Column of an override is a column of the table n.
+func (o *Override) matchesTable(n *plugin.Identifier, defaultSchema string) bool {
+	if n == nil {
+		return false
+	}
+	schema := n.GetSchema()
+	if schema == "" {
+		schema = defaultSchema
+	}
+	if o.tableCatalog != nil && !o.tableCatalog.MatchString(n.GetCatalog()) {
+		return false
+	}
+	if o.tableSchema == nil && schema != "" {
+		return false
+	}
+	if o.tableSchema != nil && !o.tableSchema.MatchString(schema) {
+		return false
+	}
+	if o.tableRel == nil && n.GetName() != "" {
+		return false
+	}
+	if o.tableRel != nil && !o.tableRel.MatchString(n.GetName()) {
+		return false
+	}
+	return true
+}
+
+// matchesColumn reports whether the Column of an override is col.
+func (o *Override) matchesColumn(req *plugin.GenerateRequest, col *plugin.Column) bool {
+	name := col.GetName()
+	if col.GetOriginalName() != "" {
+		name = col.GetOriginalName()
+	}
+	return o.Column != "" && o.columnName.MatchString(name) &&
+		o.matchesTable(col.GetTable(), req.GetCatalog().GetDefaultSchema())
+}
+
+// matchesDBType reports whether the DBType of an override is the type of col, including its nullability.
+func (o *Override) matchesDBType(col *plugin.Column) bool {
+	notNull := col.GetNotNull() || col.GetIsArray()
+	return o.DBType != "" && o.DBType == sdk.DataType(col.GetType()) &&
+		o.Nullable != notNull && o.Unsigned == col.GetUnsigned()
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestOverride_parse(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		override       string
+		wantTypeName   string
+		wantImportPath string
+		wantPackage    string
+		err            string
+	}{
+		"qualified type": {
+			override:       `{"db_type": "uuid", "go_type": "github.com/google/uuid.UUID"}`,
+			wantTypeName:   "uuid.UUID",
+			wantImportPath: "github.com/google/uuid",
+		},
+		"pointer to a qualified type": {
+			override:       `{"db_type": "uuid", "go_type": "*github.com/google/uuid.UUID"}`,
+			wantTypeName:   "*uuid.UUID",
+			wantImportPath: "github.com/google/uuid",
+		},
+		"go- prefixed package": {
+			override:       `{"db_type": "text", "go_type": "example.com/go-text.Text"}`,
+			wantTypeName:   "text.Text",
+			wantImportPath: "example.com/go-text",
+		},
+		"basic type": {
+			override:     `{"column": "users.age", "go_type": "int"}`,
+			wantTypeName: "int",
+		},
+		"object with a versioned import path": {
+			override:       `{"db_type": "uuid", "go_type": {"import": "github.com/gofrs/uuid/v5", "type": "UUID", "slice": true}}`,
+			wantTypeName:   "[]uuid.UUID",
+			wantImportPath: "github.com/gofrs/uuid/v5",
+			wantPackage:    "uuid",
+		},
+		"object with a package name": {
+			override:       `{"db_type": "uuid", "go_type": {"import": "example.com/ids", "package": "myid", "type": "ID"}}`,
+			wantTypeName:   "myid.ID",
+			wantImportPath: "example.com/ids",
+			wantPackage:    "myid",
+		},
+		"deprecated postgres_type": {
+			override:       `{"postgres_type": "uuid", "go_type": "github.com/google/uuid.UUID"}`,
+			wantTypeName:   "uuid.UUID",
+			wantImportPath: "github.com/google/uuid",
+		},
+		"unknown basic type": {
+			override: `{"db_type": "uuid", "go_type": "uuid"}`,
+			err:      `"uuid" is not a Go basic type`,
+		},
+		"column and db_type": {
+			override: `{"column": "users.id", "db_type": "uuid", "go_type": "string"}`,
+			err:      "specifying both `column`",
+		},
+		"neither column nor db_type": {
+			override: `{"go_type": "string"}`,
+			err:      "must specify one of either `column` or `db_type`",
+		},
+		"column without table": {
+			override: `{"column": "id", "go_type": "string"}`,
+			err:      "is not the proper format",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			var o Override
+			assert.NilError(t, json.Unmarshal([]byte(tt.override), &o))
+			err := o.parse("public")
+			if tt.err != "" {
+				assert.ErrorContains(t, err, tt.err)
+				return
+			}
+			assert.NilError(t, err)
+			assert.Equal(t, o.goTypeName, tt.wantTypeName)
+			assert.Equal(t, o.goImportPath, tt.wantImportPath)
+			assert.Equal(t, o.goPackage, tt.wantPackage)
+		})
+	}
+}
+
+func Test_compilePattern(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		pattern string
+		match   []string
+		noMatch []string
+	}{
+		"literal":        {pattern: "user.id", match: []string{"user.id"}, noMatch: []string{"userxid", "user.ids"}},
+		"wildcard":       {pattern: "*_id", match: []string{"user_id", "_id"}, noMatch: []string{"user_ids"}},
+		"single char":    {pattern: "id?", match: []string{"id1"}, noMatch: []string{"id", "id12"}},
+		"escaped symbol": {pattern: `a\*`, match: []string{"a*"}, noMatch: []string{"ab"}},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			re, err := compilePattern(tt.pattern)
+			assert.NilError(t, err)
+			for _, s := range tt.match {
+				assert.Assert(t, re.MatchString(s), s)
+			}
+			for _, s := range tt.noMatch {
+				assert.Assert(t, !re.MatchString(s), s)
+			}
+		})
+	}
+}
@@ -1,15 +1,17 @@
 package main
 
 import (
+	"bytes"
 	"fmt"
 	"go/ast"
 	"go/parser"
 	"go/token"
 )
 
-// parseGoCode parses a Go source file and extracts the code of a specific function by its name.
-// It returns the function code as a byte slice or an error if the function is not found.
-func parseGoCode(sourceFile string, targetFuncName string) ([]byte, error) {
+// parseGoCode parses a Go source file and extracts the code of a specific declaration by its name.
+// The declaration can be a function, a const or var declaration, or a type together with its methods.
+// It returns the declaration code as a byte slice or an error if the declaration is not found.
+func parseGoCode(sourceFile string, targetName string) ([]byte, error) {
 	srcBytes, err := templates.ReadFile(sourceFile)
 	if err != nil {
 		return nil, err
@@ -22,30 +24,62 @@ func parseGoCode(sourceFile string, targetFuncName string) ([]byte, error) {
 		return nil, err
 	}
 
-	var funcCode []byte
+	var declCodes [][]byte
 	// Scanning AST top-level declarations
-	ast.Inspect(node, func(n ast.Node) bool {
-		// Find function declarations (FuncDecl)
-		fn, ok := n.(*ast.FuncDecl)
-		if !ok {
-			return true // If it is not a function declaration, continue the search.
+	for _, decl := range node.Decls {
+		if !declares(decl, targetName) {
+			continue
 		}
+		// Get the start and end positions of the declaration
+		start := fset.Position(decl.Pos()).Offset
+		end := fset.Position(decl.End()).Offset
 
-		// Check if it matches the desired function name
-		if fn.Name.Name == targetFuncName {
-			// Get the start and end positions of the function
-			start := fset.Position(fn.Pos()).Offset
-			end := fset.Position(fn.End()).Offset
+		// Cut out the declaration part as a string from the original source code
+		declCodes = append(declCodes, srcBytes[start:end])
+	}
 
-			// Cut out the function part as a string from the original source code
-			funcCode = srcBytes[start:end]
-			return false // Finish the search because the desired function has been found.
-		}
-		return true
-	})
+	if len(declCodes) == 0 {
+		return nil, fmt.Errorf("declaration '%s' not found", targetName)
+	}
+	return bytes.Join(declCodes, []byte("\n\n")), nil
+}
 
-	if len(funcCode) == 0 {
-		return nil, fmt.Errorf("function '%s' not found", targetFuncName)
+// declares reports whether decl declares name, or is a method of the type name.
+func declares(decl ast.Decl, name string) bool {
+	switch d := decl.(type) {
+	case *ast.FuncDecl:
+		if d.Recv == nil {
+			return d.Name.Name == name
+		}
+		// Method: compare the receiver type name, e.g., "T" of "func (t *T) Method()"
+		recvType := d.Recv.List[0].Type
+		if star, ok := recvType.(*ast.StarExpr); ok {
+			recvType = star.X
+		}
+		// Generic receiver, e.g., "T" of "func (t *T[E]) Method()"
+		switch generic := recvType.(type) {
+		case *ast.IndexExpr:
+			recvType = generic.X
+		case *ast.IndexListExpr:
+			recvType = generic.X
+		}
+		ident, ok := recvType.(*ast.Ident)
+		return ok && ident.Name == name
+	case *ast.GenDecl:
+		for _, spec := range d.Specs {
+			switch s := spec.(type) {
+			case *ast.TypeSpec:
+				if s.Name.Name == name {
+					return true
+				}
+			case *ast.ValueSpec:
+				for _, n := range s.Names {
+					if n.Name == name {
+						return true
+					}
+				}
+			}
+		}
 	}
-	return funcCode, nil
+	return false
 }
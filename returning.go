@@ -0,0 +1,165 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jinzhu/inflection"
+	"github.com/sqlc-dev/plugin-sdk-go/plugin"
+	"github.com/sqlc-dev/plugin-sdk-go/sdk"
+)
+
+// modelStruct is a struct that sqlc-gen-go generates for a table in models.go.
+type modelStruct struct {
+	Table  *plugin.Identifier
+	Name   string
+	Fields []StatementParam
+}
+
+// buildModelStructs returns the structs that sqlc-gen-go generates for the tables of the catalog.
+// It mirrors buildStructs of sqlc-gen-go.
+func buildModelStructs(req *plugin.GenerateRequest, opts *Options) []modelStruct {
+	var models []modelStruct
+	for _, schema := range req.GetCatalog().GetSchemas() {
+		if schema.GetName() == "pg_catalog" || schema.GetName() == "information_schema" {
+			continue
+		}
+		for _, table := range schema.GetTables() {
+			tableName := table.GetRel().GetName()
+			if schema.GetName() != req.GetCatalog().GetDefaultSchema() {
+				tableName = schema.GetName() + "_" + tableName
+			}
+			if !opts.EmitExactTableNames {
+				tableName = singular(tableName, opts.InflectionExcludeTableNames)
+			}
+			model := modelStruct{
+				Table: &plugin.Identifier{Schema: schema.GetName(), Name: table.GetRel().GetName()},
+				Name:  structName(tableName, opts),
+			}
+			for _, col := range table.GetColumns() {
+				model.Fields = append(model.Fields, StatementParam{
+					Name: structName(col.GetName(), opts),
+					Type: goType(req, opts, col),
+				})
+			}
+			models = append(models, model)
+		}
+	}
+	return models
+}
+
+// singular returns the singular form of a table name as sqlc-gen-go does,
+// including its fixes for words that the inflection package handles incorrectly.
+func singular(name string, exclusions []string) string {
+	for _, exclusion := range exclusions {
+		if strings.EqualFold(name, exclusion) {
+			return name
+		}
+	}
+	switch strings.ToLower(name) {
+	case "campus", "meta", "metadata":
+		return name
+	case "calories":
+		return "calorie"
+	case "waves":
+		return "wave"
+	}
+	return inflection.Singular(name)
+}
+
+// buildReturning returns the type of a row that a query returns and the scan destinations of the row,
+// which are expressions on a variable "i" of the type.
+// The type is the one sqlc-gen-go uses: the Go type of the column for a single column,
+// the model struct of a table when the columns match all of its fields, and the XxxRow struct otherwise.
+func buildReturning(
+	req *plugin.GenerateRequest, opts *Options, query *plugin.Query, models []modelStruct,
+) (string, []string) {
+	columns := query.GetColumns()
+	if len(columns) == 1 && columns[0].GetEmbedTable() == nil {
+		typ := goType(req, opts, columns[0])
+		return typ, []string{scanDest(opts, "i", typ)}
+	}
+
+	for _, model := range models {
+		if len(model.Fields) != len(columns) {
+			continue
+		}
+		same := true
+		for i, f := range model.Fields {
+			c := columns[i]
+			if f.Name != structName(returnColumnName(c, i), opts) || f.Type != goType(req, opts, c) ||
+				!sdk.SameTableName(c.GetTable(), model.Table, req.GetCatalog().GetDefaultSchema()) {
+				same = false
+				break
+			}
+		}
+		if same {
+			dests := make([]string, 0, len(model.Fields))
+			for _, f := range model.Fields {
+				dests = append(dests, scanDest(opts, "i."+f.Name, f.Type))
+			}
+			return model.Name, dests
+		}
+	}
+
+	// The XxxRow struct, whose fields are named as columnsToStruct of sqlc-gen-go does
+	var dests []string
+	seen := make(map[string]int, len(columns))
+	for i, c := range columns {
+		colName := returnColumnName(c, i)
+		embed := embeddedModel(req, c.GetEmbedTable(), models)
+		if embed != nil {
+			colName = embed.Name
+		}
+		fieldName := structName(colName, opts)
+		baseFieldName := fieldName
+		if n := seen[baseFieldName]; n > 0 && !c.GetIsNamedParam() {
+			fieldName = fmt.Sprintf("%s_%d", fieldName, n+1)
+		}
+		seen[baseFieldName]++
+
+		if embed != nil {
+			for _, f := range embed.Fields {
+				dests = append(dests, scanDest(opts, "i."+fieldName+"."+f.Name, f.Type))
+			}
+			continue
+		}
+		dests = append(dests, scanDest(opts, "i."+fieldName, goType(req, opts, c)))
+	}
+	return query.GetName() + "Row", dests
+}
+
+// returnColumnName returns the name of a returned column, or "column_N" after its position if it has none.
+func returnColumnName(c *plugin.Column, i int) string {
+	if c.GetName() != "" {
+		return c.GetName()
+	}
+	return fmt.Sprintf("column_%d", i+1)
+}
+
+// embeddedModel returns the model struct of a table embedded with sqlc.embed, or nil if there is none.
+func embeddedModel(req *plugin.GenerateRequest, embed *plugin.Identifier, models []modelStruct) *modelStruct {
+	if embed == nil {
+		return nil
+	}
+	schema := embed.GetSchema()
+	if schema == "" {
+		schema = req.GetCatalog().GetDefaultSchema()
+	}
+	for i, model := range models {
+		if embed.GetCatalog() == model.Table.GetCatalog() && embed.GetName() == model.Table.GetName() &&
+			schema == model.Table.GetSchema() {
+			return &models[i]
+		}
+	}
+	return nil
+}
+
+// scanDest returns the scan destination of a variable or field.
+// As sqlc-gen-go does, slices other than []byte are scanned with pq.Array unless pgx is used.
+func scanDest(opts *Options, name, typ string) string {
+	if strings.HasPrefix(typ, "[]") && typ != "[]byte" && !opts.usesPgx() {
+		return "pq.Array(&" + name + ")"
+	}
+	return "&" + name
+}
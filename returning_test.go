@@ -0,0 +1,147 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/sqlc-dev/plugin-sdk-go/plugin"
+	"gotest.tools/v3/assert"
+)
+
+// Test_singular checks parity with the singular form of table names of sqlc-gen-go.
+func Test_singular(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		input      string
+		exclusions []string
+		want       string
+	}{
+		"plural": {
+			input: "users",
+			want:  "user",
+		},
+		"irregular plural": {
+			input: "people",
+			want:  "person",
+		},
+		"already singular": {
+			input: "user",
+			want:  "user",
+		},
+		"fixed word (campus)": {
+			input: "campus",
+			want:  "campus",
+		},
+		"fixed word (calories)": {
+			input: "calories",
+			want:  "calorie",
+		},
+		"excluded table name": {
+			input:      "news_items",
+			exclusions: []string{"NEWS_ITEMS"},
+			want:       "news_items",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, singular(tt.input, tt.exclusions), tt.want)
+		})
+	}
+}
+
+func Test_buildReturning(t *testing.T) {
+	t.Parallel()
+	int8Type := &plugin.Identifier{Name: "int8"}
+	textType := &plugin.Identifier{Name: "text"}
+	catalog := &plugin.Catalog{
+		DefaultSchema: "public",
+		Schemas: []*plugin.Schema{
+			{
+				Name: "public",
+				Tables: []*plugin.Table{
+					{
+						Rel: &plugin.Identifier{Name: "users"},
+						Columns: []*plugin.Column{
+							{Name: "id", NotNull: true, Type: int8Type},
+							{Name: "name", NotNull: true, Type: textType},
+						},
+					},
+				},
+			},
+			{
+				Name: "audit",
+				Tables: []*plugin.Table{
+					{
+						Rel: &plugin.Identifier{Name: "logs"},
+						Columns: []*plugin.Column{
+							{Name: "id", NotNull: true, Type: int8Type},
+						},
+					},
+				},
+			},
+		},
+	}
+	usersTable := &plugin.Identifier{Name: "users"}
+	tests := map[string]struct {
+		pluginOptions string
+		columns       []*plugin.Column
+		wantType      string
+		wantDests     []string
+	}{
+		"single column": {
+			columns:   []*plugin.Column{{Name: "id", NotNull: true, Type: int8Type}},
+			wantType:  "int64",
+			wantDests: []string{"&i"},
+		},
+		"all columns of a table": {
+			columns: []*plugin.Column{
+				{Name: "id", NotNull: true, Table: usersTable, Type: int8Type},
+				{Name: "name", NotNull: true, Table: usersTable, Type: textType},
+			},
+			wantType:  "User",
+			wantDests: []string{"&i.ID", "&i.Name"},
+		},
+		"all columns of a table with emit_exact_table_names": {
+			pluginOptions: `{"package": "db", "emit_exact_table_names": true}`,
+			columns: []*plugin.Column{
+				{Name: "id", NotNull: true, Table: usersTable, Type: int8Type},
+				{Name: "name", NotNull: true, Table: usersTable, Type: textType},
+			},
+			wantType:  "Users",
+			wantDests: []string{"&i.ID", "&i.Name"},
+		},
+		"columns of no table": {
+			columns: []*plugin.Column{
+				{Name: "id", NotNull: true, Type: int8Type},
+				{Name: "id", NotNull: true, Type: int8Type},
+				{NotNull: true, Type: textType},
+			},
+			wantType:  "InsertUserRow",
+			wantDests: []string{"&i.ID", "&i.ID_2", "&i.Column3"},
+		},
+		"embedded table of another schema": {
+			columns: []*plugin.Column{
+				{Name: "name", NotNull: true, Type: textType},
+				{Name: "logs", EmbedTable: &plugin.Identifier{Schema: "audit", Name: "logs"}},
+			},
+			wantType:  "InsertUserRow",
+			wantDests: []string{"&i.Name", "&i.AuditLog.ID"},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			pluginOptions := tt.pluginOptions
+			if pluginOptions == "" {
+				pluginOptions = `{"package": "db"}`
+			}
+			opts := newTestOptions(t, pluginOptions)
+			req := &plugin.GenerateRequest{Settings: &plugin.Settings{Engine: "postgresql"}, Catalog: catalog}
+			query := &plugin.Query{Name: "InsertUser", Columns: tt.columns}
+
+			gotType, gotDests := buildReturning(req, opts, query, buildModelStructs(req, opts))
+			assert.Equal(t, gotType, tt.wantType)
+			assert.DeepEqual(t, gotDests, tt.wantDests)
+		})
+	}
+}
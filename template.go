@@ -21,6 +21,7 @@ func executeTemplate(
 		"comment":    sdk.DoubleSlashComment,
 		"escape":     sdk.EscapeBacktick,
 		"hasPrefix":  strings.HasPrefix,
+		"join":       strings.Join,
 		// Helper to output string slices as Go slice literals
 		"stringSliceLiteral": func(slice []string) string {
 			if len(slice) == 0 {
@@ -0,0 +1,165 @@
+package templates
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// InsertQueryPlaceholders returns the distinct parameter numbers used in the row of the VALUES clause of an
+// INSERT statement (per-row parameters) and the ones used in the rest of the statement (statement-level
+// parameters), both in ascending order.
+// The plugin uses it at generation time so that parameters are classified exactly as
+// buildBulkInsertQuery does in the generated code.
+func InsertQueryPlaceholders(originalQuery string, numberedPlaceholders bool) ([]int, []int, error) {
+	query, err := bulkParseInsertQuery(originalQuery, numberedPlaceholders)
+	if err != nil {
+		return nil, nil, err
+	}
+	return query.splitPlaceholders()
+}
+
+// UnnestInsertQuery rewrites a PostgreSQL INSERT statement with a single-row VALUES clause into
+// "INSERT ... SELECT <row> FROM unnest($1::type[], ...) AS bulk_unnest(c1, ...)",
+// which inserts the rows of arrays bound to one parameter per row parameter, whatever the number of rows.
+// The expressions of the row are kept, with each parameter replaced by its column of bulk_unnest.
+// columnTypes are the SQL types of the row parameters in ascending order of their numbers;
+// the statement-level parameters are renumbered to follow the arrays.
+func UnnestInsertQuery(originalQuery string, columnTypes []string) (string, error) {
+	query, err := bulkParseInsertQuery(originalQuery, true)
+	if err != nil {
+		return "", err
+	}
+	rowNumbers, statementNumbers, err := query.splitPlaceholders()
+	if err != nil {
+		return "", err
+	}
+	if len(rowNumbers) != len(columnTypes) {
+		return "", fmt.Errorf("VALUES clause has %d parameters but %d column types were given",
+			len(rowNumbers), len(columnTypes))
+	}
+	for _, tok := range query.tokens[query.rowStart : query.rowEnd+1] {
+		if tok.kind == bulkSQLTokenWord && strings.EqualFold(query.text[tok.start:tok.end], "DEFAULT") {
+			return "", fmt.Errorf("DEFAULT in the VALUES clause cannot be selected from unnest: %s", originalQuery)
+		}
+	}
+
+	var queryBuilder strings.Builder
+	queryBuilder.WriteString(strings.TrimSpace(query.text[:query.tokens[query.values].start]))
+	queryBuilder.WriteString(" SELECT ")
+	// The row without its parentheses, e.g., "bulk_unnest.c1, bulk_unnest.c2::uuid, now()"
+	query.writeTokens(&queryBuilder, query.rowStart+1, query.rowEnd-1, false, func(tok bulkSQLToken) string {
+		return fmt.Sprintf("bulk_unnest.c%d", slices.Index(rowNumbers, tok.number)+1)
+	})
+	queryBuilder.WriteString(" FROM unnest(")
+	for i, typ := range columnTypes {
+		if i > 0 {
+			queryBuilder.WriteString(", ")
+		}
+		fmt.Fprintf(&queryBuilder, "$%d::%s[]", i+1, typ)
+	}
+	queryBuilder.WriteString(") AS bulk_unnest(")
+	for i := range columnTypes {
+		if i > 0 {
+			queryBuilder.WriteString(", ")
+		}
+		fmt.Fprintf(&queryBuilder, "c%d", i+1)
+	}
+	queryBuilder.WriteString(")")
+
+	if suffixStart := query.rowEnd + 1; suffixStart < len(query.tokens) {
+		queryBuilder.WriteString(" ")
+		query.writeRenumbered(&queryBuilder, suffixStart, len(query.tokens)-1, false, func(number int) int {
+			return len(columnTypes) + slices.Index(statementNumbers, number) + 1
+		})
+	}
+	return queryBuilder.String(), nil
+}
+
+// CopyFromColumns returns the columns of a PostgreSQL INSERT statement that can be run with the COPY protocol
+// and the number of the parameter bound to each of them, in the order of the column list.
+// The columns are nil if the statement has no column list.
+// A statement is eligible only if every value of its VALUES row is a distinct parameter
+// and nothing follows the row, such as ON CONFLICT or RETURNING.
+func CopyFromColumns(originalQuery string) ([]string, []int, error) {
+	return rowColumns(originalQuery, true, "COPY")
+}
+
+// LoadDataColumns returns the columns of a MySQL INSERT statement that can be run with LOAD DATA
+// and the number of the parameter bound to each of them, in the order of the column list.
+// The columns are nil if the statement has no column list.
+// A statement is eligible on the same conditions as CopyFromColumns.
+func LoadDataColumns(originalQuery string) ([]string, []int, error) {
+	return rowColumns(originalQuery, false, "LOAD DATA")
+}
+
+// rowColumns returns the columns of an INSERT statement whose VALUES row consists of distinct parameters only
+// and the number of the parameter bound to each of them. method names the statement that runs the rows in errors.
+// With numberedPlaceholders, the statement is read as PostgreSQL: unquoted identifiers are folded to lower case
+// and identifiers are quoted with double quotes. Otherwise it is read as MySQL, whose identifiers are quoted with backquotes.
+func rowColumns(originalQuery string, numberedPlaceholders bool, method string) ([]string, []int, error) {
+	query, err := bulkParseInsertQuery(originalQuery, numberedPlaceholders)
+	if err != nil {
+		return nil, nil, err
+	}
+	if query.rowEnd+1 < len(query.tokens) {
+		return nil, nil, fmt.Errorf("%s cannot run a statement with a clause after the VALUES clause: %s",
+			method, originalQuery)
+	}
+
+	var numbers []int
+	for i := query.rowStart + 1; i < query.rowEnd; i++ {
+		tok := query.tokens[i]
+		if (i-query.rowStart)%2 == 0 {
+			if tok.kind != ',' {
+				return nil, nil, fmt.Errorf("%s cannot insert an expression of the VALUES clause: %s",
+					method, originalQuery)
+			}
+			continue
+		}
+		if tok.kind != bulkSQLTokenPlaceholder || slices.Contains(numbers, tok.number) {
+			return nil, nil, fmt.Errorf("%s can insert only distinct parameters of the VALUES clause: %s",
+				method, originalQuery)
+		}
+		numbers = append(numbers, tok.number)
+	}
+
+	// The column list is the parenthesized list just before VALUES, e.g., "(id, name)"
+	listEnd := query.values - 1
+	if listEnd < 0 || query.tokens[listEnd].kind != ')' {
+		return nil, numbers, nil
+	}
+	listStart := listEnd
+	for listStart > 0 && query.tokens[listStart].kind != '(' {
+		listStart--
+	}
+	quote := `"`
+	if !numberedPlaceholders {
+		quote = "`"
+	}
+	var columns []string
+	for i := listStart + 1; i < listEnd; i++ {
+		tok := query.tokens[i]
+		text := query.text[tok.start:tok.end]
+		switch {
+		case (i-listStart)%2 == 0:
+			if tok.kind != ',' {
+				return nil, nil, fmt.Errorf("invalid column list: %s", originalQuery)
+			}
+		case tok.kind == bulkSQLTokenWord && numberedPlaceholders:
+			// Unquoted identifiers are folded to lower case
+			columns = append(columns, strings.ToLower(text))
+		case tok.kind == bulkSQLTokenWord:
+			columns = append(columns, text)
+		case tok.kind == bulkSQLTokenQuoted && strings.HasPrefix(text, quote):
+			columns = append(columns, strings.ReplaceAll(text[1:len(text)-1], quote+quote, quote))
+		default:
+			return nil, nil, fmt.Errorf("invalid column list: %s", originalQuery)
+		}
+	}
+	if len(columns) != len(numbers) {
+		return nil, nil, fmt.Errorf("column list has %d columns but VALUES clause has %d values: %s",
+			len(columns), len(numbers), originalQuery)
+	}
+	return columns, numbers, nil
+}
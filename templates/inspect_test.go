@@ -0,0 +1,201 @@
+package templates
+
+import (
+	"errors"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestUnnestInsertQuery(t *testing.T) {
+	t.Parallel()
+	type Args struct {
+		originalQuery string
+		columnTypes   []string
+	}
+	type Expected struct {
+		query string
+		err   error
+	}
+
+	tests := map[string]struct {
+		arrange func(*testing.T) (Args, Expected)
+	}{
+		"valid:Standard": {
+			arrange: func(t *testing.T) (Args, Expected) {
+				return Args{
+					originalQuery: "INSERT INTO users (id, name) VALUES ($1, $2);",
+					columnTypes:   []string{"int8", "text"},
+				}, Expected{
+					query: "INSERT INTO users (id, name) SELECT bulk_unnest.c1, bulk_unnest.c2 " +
+						"FROM unnest($1::int8[], $2::text[]) AS bulk_unnest(c1, c2)",
+					err: nil,
+				}
+			},
+		},
+		"valid:expressions of the row are kept": {
+			arrange: func(t *testing.T) (Args, Expected) {
+				return Args{
+					originalQuery: "INSERT INTO users (id, name, created_at) VALUES ($2::uuid, lower($1), now())",
+					columnTypes:   []string{"text", "uuid"},
+				}, Expected{
+					query: "INSERT INTO users (id, name, created_at) SELECT bulk_unnest.c2::uuid, lower(bulk_unnest.c1), now() " +
+						"FROM unnest($1::text[], $2::uuid[]) AS bulk_unnest(c1, c2)",
+					err: nil,
+				}
+			},
+		},
+		"valid:statement-level parameters follow the arrays": {
+			arrange: func(t *testing.T) (Args, Expected) {
+				return Args{
+					originalQuery: "INSERT INTO users (id, name) VALUES ($1, $2) " +
+						"ON CONFLICT (id) DO UPDATE SET updated_by = $3 RETURNING id",
+					columnTypes: []string{"pg_catalog.int8", "myschema.citext"},
+				}, Expected{
+					query: "INSERT INTO users (id, name) SELECT bulk_unnest.c1, bulk_unnest.c2 " +
+						"FROM unnest($1::pg_catalog.int8[], $2::myschema.citext[]) AS bulk_unnest(c1, c2) " +
+						"ON CONFLICT (id) DO UPDATE SET updated_by = $3 RETURNING id",
+					err: nil,
+				}
+			},
+		},
+		"error: DEFAULT in the row": {
+			arrange: func(t *testing.T) (Args, Expected) {
+				return Args{
+					originalQuery: "INSERT INTO users (id, name) VALUES (DEFAULT, $1)",
+					columnTypes:   []string{"text"},
+				}, Expected{
+					query: "",
+					err:   errors.New("DEFAULT in the VALUES clause cannot be selected from unnest"),
+				}
+			},
+		},
+		"error: mismatched number of column types": {
+			arrange: func(t *testing.T) (Args, Expected) {
+				return Args{
+					originalQuery: "INSERT INTO users (id, name) VALUES ($1, $2)",
+					columnTypes:   []string{"int8"},
+				}, Expected{
+					query: "",
+					err:   errors.New("VALUES clause has 2 parameters but 1 column types were given"),
+				}
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			arg, expected := tc.arrange(t)
+			result, err := UnnestInsertQuery(arg.originalQuery, arg.columnTypes)
+			if expected.err != nil {
+				assert.ErrorContains(t, err, expected.err.Error())
+				return
+			}
+			assert.NilError(t, err)
+			assert.Equal(t, result, expected.query)
+		})
+	}
+}
+
+func TestCopyFromColumns(t *testing.T) {
+	t.Parallel()
+	type Expected struct {
+		columns []string
+		numbers []int
+		err     error
+	}
+
+	tests := map[string]struct {
+		originalQuery string
+		expected      Expected
+	}{
+		"valid:column list": {
+			originalQuery: "INSERT INTO users (ID, \"Display Name\") VALUES ($2, $1);",
+			expected:      Expected{columns: []string{"id", "Display Name"}, numbers: []int{2, 1}},
+		},
+		"valid:no column list": {
+			originalQuery: "INSERT INTO users VALUES ($1, $2)",
+			expected:      Expected{columns: nil, numbers: []int{1, 2}},
+		},
+		"error: clause after the VALUES clause": {
+			originalQuery: "INSERT INTO users (id, name) VALUES ($1, $2) ON CONFLICT DO NOTHING",
+			expected:      Expected{err: errors.New("COPY cannot run a statement with a clause after the VALUES clause")},
+		},
+		"error: expression in the VALUES clause": {
+			originalQuery: "INSERT INTO users (id, name) VALUES ($1, lower($2))",
+			expected:      Expected{err: errors.New("COPY can insert only distinct parameters of the VALUES clause")},
+		},
+		"error: cast in the VALUES clause": {
+			originalQuery: "INSERT INTO users (id, name) VALUES ($1::uuid, $2)",
+			expected:      Expected{err: errors.New("COPY cannot insert an expression of the VALUES clause")},
+		},
+		"error: parameter used twice": {
+			originalQuery: "INSERT INTO users (id, name) VALUES ($1, $1)",
+			expected:      Expected{err: errors.New("COPY can insert only distinct parameters of the VALUES clause")},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			columns, numbers, err := CopyFromColumns(tc.originalQuery)
+			if tc.expected.err != nil {
+				assert.ErrorContains(t, err, tc.expected.err.Error())
+				return
+			}
+			assert.NilError(t, err)
+			assert.DeepEqual(t, columns, tc.expected.columns)
+			assert.DeepEqual(t, numbers, tc.expected.numbers)
+		})
+	}
+}
+
+func TestLoadDataColumns(t *testing.T) {
+	t.Parallel()
+	type Expected struct {
+		columns []string
+		numbers []int
+		err     error
+	}
+
+	tests := map[string]struct {
+		originalQuery string
+		expected      Expected
+	}{
+		"valid:column list": {
+			originalQuery: "INSERT INTO users (ID, `Display``Name`) VALUES (?, ?);",
+			expected:      Expected{columns: []string{"ID", "Display`Name"}, numbers: []int{1, 2}},
+		},
+		"valid:no column list": {
+			originalQuery: "INSERT INTO users VALUES (?, ?)",
+			expected:      Expected{columns: nil, numbers: []int{1, 2}},
+		},
+		"error: clause after the VALUES clause": {
+			originalQuery: "INSERT INTO users (id, name) VALUES (?, ?) ON DUPLICATE KEY UPDATE name = VALUES(name)",
+			expected:      Expected{err: errors.New("LOAD DATA cannot run a statement with a clause after the VALUES clause")},
+		},
+		"error: expression in the VALUES clause": {
+			originalQuery: "INSERT INTO users (id, name) VALUES (?, LOWER(?))",
+			expected:      Expected{err: errors.New("LOAD DATA can insert only distinct parameters of the VALUES clause")},
+		},
+		"error: double-quoted column": {
+			originalQuery: "INSERT INTO users (id, \"name\") VALUES (?, ?)",
+			expected:      Expected{err: errors.New("invalid column list")},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			columns, numbers, err := LoadDataColumns(tc.originalQuery)
+			if tc.expected.err != nil {
+				assert.ErrorContains(t, err, tc.expected.err.Error())
+				return
+			}
+			assert.NilError(t, err)
+			assert.DeepEqual(t, columns, tc.expected.columns)
+			assert.DeepEqual(t, numbers, tc.expected.numbers)
+		})
+	}
+}
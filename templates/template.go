@@ -1,9 +1,20 @@
 package templates
 
 import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
 	"fmt"
+	"io"
+	"iter"
 	"reflect"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // extractFieldValues takes a slice of a structure and an ordered list of field names to extract,
@@ -40,85 +51,898 @@ func extractFieldValues[T any](args []T, paramFieldNames []string) ([]any, error
 }
 
 // buildBulkInsertQuery builds a SQL query string for bulk inserts.
+// The row of the original VALUES clause is copied once per row, so literals, function calls and casts
+// are preserved and only the parameter markers are renumbered.
+// Parameters outside the VALUES clause (e.g., in "ON CONFLICT ... DO UPDATE SET") are statement-level parameters;
+// they are bound once, after the parameters of all rows.
 // originalQuery: the original INSERT statement (e.g., "INSERT INTO users (id, name) VALUES ($1, $2)")
 // numArgs: number of rows of data to insert
 // numParamsPerArg: number of parameters per row (number of columns)
-func buildBulkInsertQuery(originalQuery string, numArgs int, numParamsPerArg int) (string, error) {
+// numStatementParams: number of statement-level parameters
+// numberedPlaceholders: the statement uses PostgreSQL-style numbered placeholders ($1, $2, ...) instead of "?"
+func buildBulkInsertQuery(
+	originalQuery string, numArgs int, numParamsPerArg int, numStatementParams int, numberedPlaceholders bool,
+) (string, error) {
+	query, rowNumbers, statementNumbers, err := parseBulkInsertQuery(
+		originalQuery, numArgs, numParamsPerArg, numStatementParams, numberedPlaceholders,
+	)
+	if err != nil {
+		return "", err
+	}
+
+	var queryBuilder strings.Builder
+	// Prefix the query up to "VALUES".
+	// (e.g., "INSERT INTO users (id, name)")
+	queryBuilder.WriteString(strings.TrimSpace(query.text[:query.tokens[query.values].start]))
+	queryBuilder.WriteString(" VALUES ")
+	for i := range numArgs {
+		if i > 0 {
+			queryBuilder.WriteString(",")
+		}
+		// Each row is a copy of the original row with its parameters renumbered: ($1,$2),($3,$4),...
+		query.writeRenumbered(&queryBuilder, query.rowStart, query.rowEnd, true, func(number int) int {
+			return i*numParamsPerArg + slices.Index(rowNumbers, number) + 1
+		})
+	}
+
+	// Append the suffix if it exists.
+	// (e.g., "ON DUPLICATE KEY UPDATE ...", "ON CONFLICT ...", "RETURNING ...")
+	if suffixStart := query.rowEnd + 1; suffixStart < len(query.tokens) {
+		queryBuilder.WriteString(" ")
+		query.writeRenumbered(&queryBuilder, suffixStart, len(query.tokens)-1, false, func(number int) int {
+			return numArgs*numParamsPerArg + slices.Index(statementNumbers, number) + 1
+		})
+	}
+	return queryBuilder.String(), nil
+}
+
+// bulkOrderedChunkSize is the maximum number of rows of a statement built by buildOrderedBulkInsertQuery.
+const bulkOrderedChunkSize = 100
+
+// buildOrderedBulkInsertQuery builds a PostgreSQL query string for bulk inserts
+// whose returned rows can be mapped back to the rows of data to insert.
+// Each row is inserted by a data-modifying CTE that is a copy of the original statement with a single row,
+// and the rows returned by each CTE are preceded by a column of the 0-based index of its row:
+//
+//	WITH bulk_0 AS (INSERT ... VALUES ($1,$2) RETURNING ...), bulk_1 AS (INSERT ... VALUES ($3,$4) RETURNING ...)
+//	SELECT 0, * FROM bulk_0 UNION ALL SELECT 1, * FROM bulk_1
+//
+// The parameters are bound as with buildBulkInsertQuery, and statement-level parameters are shared by all CTEs.
+func buildOrderedBulkInsertQuery(
+	originalQuery string, numArgs int, numParamsPerArg int, numStatementParams int,
+) (string, error) {
+	query, rowNumbers, statementNumbers, err := parseBulkInsertQuery(
+		originalQuery, numArgs, numParamsPerArg, numStatementParams, true,
+	)
+	if err != nil {
+		return "", err
+	}
+
+	prefix := strings.TrimSpace(query.text[:query.tokens[query.values].start])
+	var queryBuilder strings.Builder
+	queryBuilder.WriteString("WITH ")
+	for i := range numArgs {
+		if i > 0 {
+			queryBuilder.WriteString(", ")
+		}
+		fmt.Fprintf(&queryBuilder, "bulk_%d AS (%s VALUES ", i, prefix)
+		query.writeRenumbered(&queryBuilder, query.rowStart, query.rowEnd, true, func(number int) int {
+			return i*numParamsPerArg + slices.Index(rowNumbers, number) + 1
+		})
+		if suffixStart := query.rowEnd + 1; suffixStart < len(query.tokens) {
+			queryBuilder.WriteString(" ")
+			query.writeRenumbered(&queryBuilder, suffixStart, len(query.tokens)-1, false, func(number int) int {
+				return numArgs*numParamsPerArg + slices.Index(statementNumbers, number) + 1
+			})
+		}
+		queryBuilder.WriteString(")")
+	}
+	for i := range numArgs {
+		if i > 0 {
+			queryBuilder.WriteString(" UNION ALL")
+		}
+		fmt.Fprintf(&queryBuilder, " SELECT %d, * FROM bulk_%d", i, i)
+	}
+	return queryBuilder.String(), nil
+}
+
+// parseBulkInsertQuery parses the original INSERT statement of a bulk insert of numArgs rows
+// and returns it with the parameter numbers of its row and of the rest of the statement, in ascending order.
+// A number that appears more than once (e.g., "($1, $1)") is bound only once per row or statement.
+// The numbers of parameters must match numParamsPerArg and numStatementParams.
+func parseBulkInsertQuery(
+	originalQuery string, numArgs int, numParamsPerArg int, numStatementParams int, numberedPlaceholders bool,
+) (*bulkInsertQuery, []int, []int, error) {
 	if numArgs == 0 {
-		return "", fmt.Errorf("number of arguments (rows) for bulk insert cannot be zero")
+		return nil, nil, nil, fmt.Errorf("number of arguments (rows) for bulk insert cannot be zero")
 	}
 	if numParamsPerArg == 0 {
-		return "", fmt.Errorf("number of parameters per argument (columns) for bulk insert cannot be zero")
+		return nil, nil, nil, fmt.Errorf("number of parameters per argument (columns) for bulk insert cannot be zero")
 	}
 
-	// Extract the "INSERT INTO table (col1, col2) VALUES " part from the original query
-	// First, remove the trailing semicolon, if any
-	trimmedQuery := strings.TrimSpace(originalQuery)
-	if strings.HasSuffix(trimmedQuery, ";") {
-		trimmedQuery = trimmedQuery[:len(trimmedQuery)-1]
+	query, err := bulkParseInsertQuery(originalQuery, numberedPlaceholders)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	rowNumbers, statementNumbers, err := query.splitPlaceholders()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if len(rowNumbers) != numParamsPerArg {
+		return nil, nil, nil, fmt.Errorf(
+			"VALUES clause has %d parameters but %d parameters per argument (columns) were given",
+			len(rowNumbers), numParamsPerArg)
+	}
+	if len(statementNumbers) != numStatementParams {
+		return nil, nil, nil, fmt.Errorf("query has %d statement-level parameters but %d were given",
+			len(statementNumbers), numStatementParams)
 	}
+	return query, rowNumbers, statementNumbers, nil
+}
+
+// bulkChunkSize returns the maximum number of rows of a bulk insert statement.
+// A statement binds numParamsPerArg parameters per row plus numStatementParams parameters,
+// which must not exceed maxPlaceholders, the limit of the database engine.
+// batchSize further limits the number of rows if it is positive.
+func bulkChunkSize(numParamsPerArg int, numStatementParams int, maxPlaceholders int, batchSize int) (int, error) {
+	if numParamsPerArg <= 0 {
+		return 0, fmt.Errorf("number of parameters per argument (columns) for bulk insert must be positive")
+	}
+	chunkSize := (maxPlaceholders - numStatementParams) / numParamsPerArg
+	if chunkSize <= 0 {
+		return 0, fmt.Errorf("a row with %d parameters and %d statement-level parameters exceeds the limit of %d placeholders",
+			numParamsPerArg, numStatementParams, maxPlaceholders)
+	}
+	if batchSize > 0 {
+		chunkSize = min(chunkSize, batchSize)
+	}
+	return chunkSize, nil
+}
 
-	// Find the start of the suffix (e.g., "ON DUPLICATE", "ON CONFLICT")
-	// We must do this *before* searching for "VALUES" to avoid matching "VALUES()"
-	// functions inside the suffix.
-	var querySuffixStr string
-	var queryWithoutSuffix string
+// bulkChunkEnds splits the rows of preparedValues into chunks of bulk insert statements
+// and returns the end index (exclusive) of the rows of each chunk.
+// A chunk has at most chunkSize rows. If maxBytes is positive, a chunk is also closed
+// before the estimated size of its statement exceeds maxBytes; a row that exceeds maxBytes by itself makes a chunk alone.
+// The size of a statement is estimated as the length of originalQuery,
+// the sizes of statementValues, and the sizes of the values of its rows with a few bytes per placeholder.
+func bulkChunkEnds(
+	originalQuery string, preparedValues []any, statementValues []any, numParamsPerArg int, chunkSize int, maxBytes int,
+) []int {
+	numArgs := len(preparedValues) / numParamsPerArg
+	if numArgs == 0 {
+		return nil
+	}
+	var chunkEnds []int
+	if maxBytes <= 0 {
+		for end := chunkSize; end < numArgs; end += chunkSize {
+			chunkEnds = append(chunkEnds, end)
+		}
+		return append(chunkEnds, numArgs)
+	}
 
-	// Use LastIndex to find the main clause
-	onDuplicateUpperIndex := strings.LastIndex(strings.ToUpper(trimmedQuery), "ON DUPLICATE KEY UPDATE")
-	onConflictUpperIndex := strings.LastIndex(strings.ToUpper(trimmedQuery), "ON CONFLICT")
-	returningUpperIndex := strings.LastIndex(strings.ToUpper(trimmedQuery), "RETURNING")
+	// placeholderBytes is the size of a placeholder and its separator (e.g., ",$123")
+	const placeholderBytes = 8
+	baseBytes := len(originalQuery)
+	for _, value := range statementValues {
+		baseBytes += bulkEstimateValueSize(value)
+	}
+	start, chunkBytes := 0, baseBytes
+	for i := range numArgs {
+		rowBytes := 0
+		for _, value := range preparedValues[i*numParamsPerArg : (i+1)*numParamsPerArg] {
+			rowBytes += bulkEstimateValueSize(value) + placeholderBytes
+		}
+		if i > start && (i-start == chunkSize || chunkBytes+rowBytes > maxBytes) {
+			chunkEnds = append(chunkEnds, i)
+			start, chunkBytes = i, baseBytes
+		}
+		chunkBytes += rowBytes
+	}
+	return append(chunkEnds, numArgs)
+}
 
-	// Find the earliest starting position of any suffix keyword
-	suffixBoundary := len(trimmedQuery)
-	if onDuplicateUpperIndex != -1 {
-		suffixBoundary = onDuplicateUpperIndex
+// bulkEstimateValueSize estimates the number of bytes that a parameter value takes in a statement sent to the server.
+// Strings take their length with quotes, byte slices twice their length as hexadecimal literals,
+// and the other values the length of their text representation.
+func bulkEstimateValueSize(value any) int {
+	rv := reflect.ValueOf(value)
+	if rv.Kind() == reflect.Pointer && rv.IsNil() {
+		return len("NULL")
 	}
-	if onConflictUpperIndex != -1 && onConflictUpperIndex < suffixBoundary {
-		suffixBoundary = onConflictUpperIndex
+	if valuer, ok := value.(driver.Valuer); ok {
+		if v, err := valuer.Value(); err == nil {
+			rv = reflect.ValueOf(v)
+		}
 	}
-	if returningUpperIndex != -1 && returningUpperIndex < suffixBoundary {
-		suffixBoundary = returningUpperIndex
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return len("NULL")
+		}
+		rv = rv.Elem()
 	}
 
-	if suffixBoundary < len(trimmedQuery) {
-		// Suffix found
-		queryWithoutSuffix = trimmedQuery[:suffixBoundary]
-		querySuffixStr = " " + strings.TrimSpace(trimmedQuery[suffixBoundary:])
-	} else {
-		// No suffix
-		queryWithoutSuffix = trimmedQuery
-		querySuffixStr = ""
+	switch rv.Kind() {
+	case reflect.Invalid:
+		return len("NULL")
+	case reflect.Bool:
+		return len("FALSE")
+	case reflect.String:
+		return rv.Len() + 2
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return len(strconv.FormatInt(rv.Int(), 10))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return len(strconv.FormatUint(rv.Uint(), 10))
+	case reflect.Slice:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			return rv.Len()*2 + 3
+		}
 	}
+	return len(fmt.Sprint(rv.Interface()))
+}
 
-	// search "VALUES" (case insensitive) in the part before the suffix
-	valuesUpperIndex := strings.LastIndex(strings.ToUpper(queryWithoutSuffix), "VALUES")
-	if valuesUpperIndex == -1 {
-		return "", fmt.Errorf("invalid query format: VALUES clause not found in original query: %s", originalQuery)
+// bulkInsertSeq pulls rows from seq and calls flush with each chunk of chunkSize rows and with the rest at the end,
+// so that no more than chunkSize rows are held at a time. It returns the number of rows flushed.
+// It stops at the first error of seq or flush, or when ctx is done; the rows pulled after the last flush are dropped then.
+// The slice passed to flush is reused for the next chunk, so flush must not retain it.
+func bulkInsertSeq[T any](
+	ctx context.Context, seq iter.Seq2[T, error], chunkSize int, flush func(rows []T) error,
+) (int64, error) {
+	if chunkSize <= 0 {
+		return 0, fmt.Errorf("chunk size must be positive: %d", chunkSize)
+	}
+	var total int64
+	chunk := make([]T, 0, chunkSize)
+	for row, err := range seq {
+		if err != nil {
+			return total, err
+		}
+		if err := ctx.Err(); err != nil {
+			return total, err
+		}
+		chunk = append(chunk, row)
+		if len(chunk) < chunkSize {
+			continue
+		}
+		if err := flush(chunk); err != nil {
+			return total, err
+		}
+		total += int64(len(chunk))
+		chunk = chunk[:0]
+	}
+	if len(chunk) == 0 {
+		return total, nil
+	}
+	if err := ctx.Err(); err != nil {
+		return total, err
 	}
+	if err := flush(chunk); err != nil {
+		return total, err
+	}
+	return total + int64(len(chunk)), nil
+}
 
-	// Prefix the query up to "VALUES".
-	// (e.g., "INSERT INTO users (id, name)")
-	// Add "VALUES" to this
-	queryPrefixStr := strings.TrimSpace(trimmedQuery[:valuesUpperIndex]) + " VALUES "
+// bulkSeq2 converts seq into an iter.Seq2 whose errors are all nil.
+func bulkSeq2[T any](seq iter.Seq[T]) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		for v := range seq {
+			if !yield(v, nil) {
+				return
+			}
+		}
+	}
+}
 
-	var queryBuilder strings.Builder
-	queryBuilder.WriteString(queryPrefixStr)
+// bulkWriter buffers rows and passes them to flush when size rows are buffered, when flushed explicitly
+// and when closed. When flush fails, the rows stay buffered and are passed again to the next flush.
+// It is not safe for concurrent use.
+type bulkWriter[T any] struct {
+	size   int
+	flush  func(ctx context.Context, rows []T) error
+	rows   []T
+	closed bool
+}
 
-	valueStrings := make([]string, numArgs)
-	for i := range numArgs {
-		placeholders := make([]string, numParamsPerArg)
-		for j := range numParamsPerArg {
-			placeholders[j] = "?"
+// newBulkWriter returns a bulkWriter that flushes every size rows with flush.
+// The slice passed to flush is reused after it succeeds, so flush must not retain it.
+func newBulkWriter[T any](size int, flush func(ctx context.Context, rows []T) error) *bulkWriter[T] {
+	return &bulkWriter[T]{size: size, flush: flush}
+}
+
+// add buffers row and flushes the rows if size rows are buffered.
+func (w *bulkWriter[T]) add(ctx context.Context, row T) error {
+	if w.closed {
+		return errors.New("bulk writer is closed")
+	}
+	w.rows = append(w.rows, row)
+	if len(w.rows) < w.size {
+		return nil
+	}
+	return w.flushRows(ctx)
+}
+
+// flushRows flushes the buffered rows, if any.
+func (w *bulkWriter[T]) flushRows(ctx context.Context) error {
+	if w.closed {
+		return errors.New("bulk writer is closed")
+	}
+	if len(w.rows) == 0 {
+		return nil
+	}
+	if err := w.flush(ctx, w.rows); err != nil {
+		return err
+	}
+	w.rows = w.rows[:0]
+	return nil
+}
+
+// close flushes the buffered rows and closes the writer, after which rows cannot be added.
+// If the flush fails, the writer stays open, so that close can be retried.
+// Closing a closed writer does nothing.
+func (w *bulkWriter[T]) close(ctx context.Context) error {
+	if w.closed {
+		return nil
+	}
+	if err := w.flushRows(ctx); err != nil {
+		return err
+	}
+	w.closed = true
+	w.rows = nil
+	return nil
+}
+
+// bulkAsyncWriter passes the rows added from any goroutine to flush from a background goroutine,
+// when size rows are buffered, at each tick of a ticker and when closed.
+// When flush fails, the error and the rows are passed to onError, so that no row is lost silently.
+type bulkAsyncWriter[T any] struct {
+	size    int
+	flush   func(ctx context.Context, rows []T) error
+	onError func(err error, rows []T)
+	rows    chan T
+	// mu guards closed, so that no add starts after close; it is never held while blocking
+	mu     sync.RWMutex
+	closed bool
+	// closing is closed by close, which makes the blocked adds give up
+	closing chan struct{}
+	// adding counts the adds in progress, and added is closed when they ended after close
+	adding sync.WaitGroup
+	added  chan struct{}
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// newBulkAsyncWriter starts a bulkAsyncWriter that flushes every size rows and at each tick of the ticker
+// that newTicker returns for interval. Up to buffer rows are queued while a flush is in progress.
+// The flushes run with ctx without its cancellation, so that the rows can be flushed after ctx is done;
+// they are canceled when close gives up.
+func newBulkAsyncWriter[T any](
+	ctx context.Context, size int, interval time.Duration, buffer int,
+	flush func(ctx context.Context, rows []T) error, onError func(err error, rows []T),
+	newTicker func(d time.Duration) (<-chan time.Time, func()),
+) *bulkAsyncWriter[T] {
+	flushCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	w := &bulkAsyncWriter[T]{
+		size:    size,
+		flush:   flush,
+		onError: onError,
+		rows:    make(chan T, buffer),
+		closing: make(chan struct{}),
+		added:   make(chan struct{}),
+		cancel:  cancel,
+		done:    make(chan struct{}),
+	}
+	tick, stop := newTicker(interval)
+	go w.run(flushCtx, tick, stop)
+	return w
+}
+
+// run buffers the rows and flushes them until the writer is closed and no add is in progress,
+// and then flushes the rest.
+func (w *bulkAsyncWriter[T]) run(ctx context.Context, tick <-chan time.Time, stop func()) {
+	defer close(w.done)
+	defer w.cancel()
+	defer stop()
+	rows := make([]T, 0, w.size)
+	flush := func() {
+		if len(rows) == 0 {
+			return
+		}
+		if err := w.flush(ctx, rows); err != nil {
+			w.onError(err, slices.Clone(rows))
+		}
+		rows = rows[:0]
+	}
+	receive := func(row T) {
+		rows = append(rows, row)
+		if len(rows) >= w.size {
+			flush()
+		}
+	}
+	for {
+		select {
+		case row := <-w.rows:
+			receive(row)
+		case <-tick:
+			flush()
+		case <-w.added:
+			// No row is sent anymore, so the queue is drained without blocking
+			for {
+				select {
+				case row := <-w.rows:
+					receive(row)
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+// add queues row. It blocks while the queue is full, until ctx is done or the writer is closed.
+func (w *bulkAsyncWriter[T]) add(ctx context.Context, row T) error {
+	w.mu.RLock()
+	if w.closed {
+		w.mu.RUnlock()
+		return errors.New("bulk writer is closed")
+	}
+	w.adding.Add(1)
+	w.mu.RUnlock()
+	defer w.adding.Done()
+	select {
+	case w.rows <- row:
+		return nil
+	case <-w.closing:
+		return errors.New("bulk writer is closed")
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// close stops accepting rows and waits until the queued and buffered rows are flushed.
+// The adds blocked on the full queue give up with an error.
+// If ctx is done first, the flushes are canceled, so the rows that are not flushed yet are passed to onError,
+// and ctx.Err() is returned after the background goroutine ends.
+func (w *bulkAsyncWriter[T]) close(ctx context.Context) error {
+	w.mu.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.closing)
+		go func() {
+			w.adding.Wait()
+			close(w.added)
+		}()
+	}
+	w.mu.Unlock()
+	select {
+	case <-w.done:
+		return nil
+	case <-ctx.Done():
+		w.cancel()
+		<-w.done
+		return ctx.Err()
+	}
+}
+
+// bulkTicker returns the channel of a time.Ticker for d and the function that stops it.
+func bulkTicker(d time.Duration) (<-chan time.Time, func()) {
+	ticker := time.NewTicker(d)
+	return ticker.C, ticker.Stop
+}
+
+// bulkLoadDataReaderID numbers the reader handlers of bulkLoadDataLocalInfile,
+// so that concurrent calls use distinct names.
+var bulkLoadDataReaderID atomic.Uint64
+
+// bulkLoadDataLocalInfile inserts rows into a MySQL table with LOAD DATA LOCAL INFILE
+// and returns the number of rows affected.
+// values are the values of the rows flattened in the order of columns, as extractFieldValues returns them.
+// They are streamed as tab-separated values through a reader handler that is registered with register
+// and removed with deregister when the statement completes,
+// i.e., RegisterReaderHandler and DeregisterReaderHandler of github.com/go-sql-driver/mysql.
+func bulkLoadDataLocalInfile(
+	ctx context.Context,
+	db interface {
+		ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	},
+	table []string, columns []string, values []any,
+	register func(name string, handler func() io.Reader), deregister func(name string),
+) (int64, error) {
+	if len(columns) == 0 || len(values)%len(columns) != 0 {
+		return 0, fmt.Errorf("%d values cannot be split into rows of %d columns", len(values), len(columns))
+	}
+
+	var reader *io.PipeReader
+	name := "bulk_load_data_" + strconv.FormatUint(bulkLoadDataReaderID.Add(1), 10)
+	register(name, func() io.Reader {
+		var writer *io.PipeWriter
+		reader, writer = io.Pipe()
+		go func() {
+			writer.CloseWithError(bulkWriteLoadDataRows(writer, values, len(columns)))
+		}()
+		return reader
+	})
+	defer deregister(name)
+
+	quote := func(identifier string) string {
+		return "`" + strings.ReplaceAll(identifier, "`", "``") + "`"
+	}
+	var sb strings.Builder
+	sb.WriteString("LOAD DATA LOCAL INFILE 'Reader::" + name + "' INTO TABLE ")
+	for i, identifier := range table {
+		if i > 0 {
+			sb.WriteByte('.')
 		}
-		valueStrings[i] = fmt.Sprintf("(%s)", strings.Join(placeholders, ","))
+		sb.WriteString(quote(identifier))
 	}
-	queryBuilder.WriteString(strings.Join(valueStrings, ","))
+	sb.WriteString(` CHARACTER SET utf8mb4 FIELDS TERMINATED BY '\t' ESCAPED BY '\\' LINES TERMINATED BY '\n' (`)
+	for i, column := range columns {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(quote(column))
+	}
+	sb.WriteByte(')')
 
-	// Append the suffix if it exists.
-	if querySuffixStr != "" {
-		queryBuilder.WriteString(querySuffixStr)
+	result, err := db.ExecContext(ctx, sb.String())
+	if reader != nil {
+		// Stops the writer if the driver did not read all rows
+		reader.Close()
+	}
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// bulkWriteLoadDataRows writes values to w as lines of numColumns tab-separated values,
+// escaped as LOAD DATA reads them with ESCAPED BY '\\'.
+func bulkWriteLoadDataRows(w io.Writer, values []any, numColumns int) error {
+	const flushSize = 64 * 1024
+	buf := make([]byte, 0, flushSize)
+	for i, value := range values {
+		v, err := driver.DefaultParameterConverter.ConvertValue(value)
+		if err != nil {
+			return fmt.Errorf("args[%d]: column %d: %w", i/numColumns, i%numColumns, err)
+		}
+		if buf, err = bulkAppendLoadDataValue(buf, v); err != nil {
+			return fmt.Errorf("args[%d]: column %d: %w", i/numColumns, i%numColumns, err)
+		}
+		if (i+1)%numColumns == 0 {
+			buf = append(buf, '\n')
+		} else {
+			buf = append(buf, '\t')
+		}
+		if len(buf) >= flushSize || i == len(values)-1 {
+			if _, err := w.Write(buf); err != nil {
+				return err
+			}
+			buf = buf[:0]
+		}
+	}
+	return nil
+}
+
+// bulkAppendLoadDataValue appends a value converted by driver.DefaultParameterConverter to buf as a field of LOAD DATA.
+// NULL is written as \N, and backslashes, tabs, newlines, carriage returns and NUL bytes are escaped with a backslash.
+// time.Time is written in UTC, the default location of github.com/go-sql-driver/mysql.
+func bulkAppendLoadDataValue(buf []byte, value driver.Value) ([]byte, error) {
+	appendEscaped := func(buf []byte, s string) []byte {
+		for i := 0; i < len(s); i++ {
+			switch c := s[i]; c {
+			case '\\':
+				buf = append(buf, '\\', '\\')
+			case '\t':
+				buf = append(buf, '\\', 't')
+			case '\n':
+				buf = append(buf, '\\', 'n')
+			case '\r':
+				buf = append(buf, '\\', 'r')
+			case 0:
+				buf = append(buf, '\\', '0')
+			default:
+				buf = append(buf, c)
+			}
+		}
+		return buf
+	}
+
+	switch v := value.(type) {
+	case nil:
+		return append(buf, '\\', 'N'), nil
+	case int64:
+		return strconv.AppendInt(buf, v, 10), nil
+	case uint64:
+		return strconv.AppendUint(buf, v, 10), nil
+	case float64:
+		return strconv.AppendFloat(buf, v, 'g', -1, 64), nil
+	case bool:
+		if v {
+			return append(buf, '1'), nil
+		}
+		return append(buf, '0'), nil
+	case []byte:
+		// A nil slice is NULL, as the driver binds it
+		if v == nil {
+			return append(buf, '\\', 'N'), nil
+		}
+		return appendEscaped(buf, string(v)), nil
+	case string:
+		return appendEscaped(buf, v), nil
+	case time.Time:
+		return v.UTC().AppendFormat(buf, "2006-01-02 15:04:05.999999"), nil
+	}
+	return nil, fmt.Errorf("unsupported type %T", value)
+}
+
+// bulkInsertQuery is an INSERT statement split into tokens by bulkParseInsertQuery.
+type bulkInsertQuery struct {
+	// text is the statement without surrounding spaces and the trailing semicolon
+	text   string
+	tokens []bulkSQLToken
+	// values is the index of the VALUES keyword token
+	values int
+	// rowStart and rowEnd are the indexes of the "(" and ")" tokens enclosing the row of the VALUES clause
+	rowStart int
+	rowEnd   int
+}
+
+// bulkParseInsertQuery tokenizes an INSERT statement and locates the row of its VALUES clause.
+func bulkParseInsertQuery(originalQuery string, numberedPlaceholders bool) (*bulkInsertQuery, error) {
+	// First, remove the trailing semicolon, if any
+	text := strings.TrimSuffix(strings.TrimSpace(originalQuery), ";")
+	query := &bulkInsertQuery{
+		text:   text,
+		tokens: bulkTokenizeSQL(text, numberedPlaceholders),
+		values: -1,
 	}
 
-	return strings.TrimSpace(queryBuilder.String()), nil
+	// search "VALUES" (case insensitive) outside parentheses,
+	// so that "VALUES()" functions in the suffix and sub-queries are not matched.
+	depth := 0
+	for i, tok := range query.tokens {
+		switch tok.kind {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case bulkSQLTokenWord:
+			if depth == 0 && strings.EqualFold(text[tok.start:tok.end], "VALUES") {
+				query.values = i
+			}
+		}
+		if query.values != -1 {
+			break
+		}
+	}
+	if query.values == -1 {
+		return nil, fmt.Errorf("invalid query format: VALUES clause not found in original query: %s", originalQuery)
+	}
+
+	query.rowStart = query.values + 1
+	if query.rowStart >= len(query.tokens) || query.tokens[query.rowStart].kind != '(' {
+		return nil, fmt.Errorf("invalid query format: VALUES clause has no row in original query: %s", originalQuery)
+	}
+	depth = 0
+	for i := query.rowStart; i < len(query.tokens); i++ {
+		switch query.tokens[i].kind {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		}
+		if depth == 0 {
+			query.rowEnd = i
+			break
+		}
+	}
+	if query.rowEnd == 0 {
+		return nil, fmt.Errorf("invalid query format: unbalanced parentheses in original query: %s", originalQuery)
+	}
+	if next := query.rowEnd + 1; next < len(query.tokens) && query.tokens[next].kind == ',' {
+		return nil, fmt.Errorf("invalid query format: VALUES clause has more than one row in original query: %s",
+			originalQuery)
+	}
+	return query, nil
+}
+
+// splitPlaceholders returns the distinct parameter numbers used in the row of the VALUES clause
+// and the ones used in the rest of the statement, both in ascending order.
+func (q *bulkInsertQuery) splitPlaceholders() ([]int, []int, error) {
+	if prefixNumbers := q.placeholderNumbers(0, q.values); len(prefixNumbers) > 0 {
+		return nil, nil, fmt.Errorf("invalid query format: parameters before the VALUES clause are not supported: %s",
+			q.text)
+	}
+	rowNumbers := q.placeholderNumbers(q.rowStart, q.rowEnd)
+	statementNumbers := q.placeholderNumbers(q.rowEnd+1, len(q.tokens)-1)
+	for _, number := range statementNumbers {
+		if slices.Contains(rowNumbers, number) {
+			return nil, nil, fmt.Errorf(
+				"invalid query format: parameter %d is used both in and outside the VALUES clause: %s", number, q.text)
+		}
+	}
+	return rowNumbers, statementNumbers, nil
+}
+
+// placeholderNumbers returns the distinct parameter numbers of the placeholders
+// between the tokens[from] and tokens[to] (inclusive), in ascending order.
+func (q *bulkInsertQuery) placeholderNumbers(from, to int) []int {
+	numbers := make([]int, 0)
+	for _, tok := range q.tokens[from : to+1] {
+		if tok.kind == bulkSQLTokenPlaceholder && !slices.Contains(numbers, tok.number) {
+			numbers = append(numbers, tok.number)
+		}
+	}
+	slices.Sort(numbers)
+	return numbers
+}
+
+// writeRenumbered writes the tokens from tokens[from] to tokens[to] (inclusive),
+// replacing the number of each placeholder with renumber(number).
+// "?" placeholders are written as they are because they are bound by position.
+// Comments are dropped. With compact, spaces are collapsed, except around "(", ")" and "," where they are removed.
+func (q *bulkInsertQuery) writeRenumbered(
+	sb *strings.Builder, from, to int, compact bool, renumber func(number int) int,
+) {
+	q.writeTokens(sb, from, to, compact, func(tok bulkSQLToken) string {
+		if tok.end-tok.start == 1 {
+			return q.text[tok.start:tok.end]
+		}
+		// Keep the marker character ("$" or "?") and replace the number.
+		return q.text[tok.start:tok.start+1] + strconv.Itoa(renumber(tok.number))
+	})
+}
+
+// writeTokens writes the tokens from tokens[from] to tokens[to] (inclusive) as writeRenumbered does,
+// replacing each placeholder with placeholder(tok).
+func (q *bulkInsertQuery) writeTokens(
+	sb *strings.Builder, from, to int, compact bool, placeholder func(tok bulkSQLToken) string,
+) {
+	for i := from; i <= to; i++ {
+		tok := q.tokens[i]
+		if i > from {
+			prev := q.tokens[i-1]
+			gap := q.text[prev.end:tok.start]
+			switch {
+			case gap == "":
+			case compact:
+				if !strings.ContainsRune("(),", rune(prev.kind)) && !strings.ContainsRune("(),", rune(tok.kind)) {
+					sb.WriteByte(' ')
+				}
+			case strings.TrimSpace(gap) == "":
+				sb.WriteString(gap)
+			default:
+				// The gap contains a comment
+				sb.WriteByte(' ')
+			}
+		}
+		if tok.kind == bulkSQLTokenPlaceholder {
+			sb.WriteString(placeholder(tok))
+			continue
+		}
+		sb.WriteString(q.text[tok.start:tok.end])
+	}
+}
+
+// bulkSQLToken kinds. Punctuation tokens use the punctuation character itself as the kind (e.g., '(', ',').
+const (
+	bulkSQLTokenWord        = 'w' // keyword or unquoted identifier
+	bulkSQLTokenQuoted      = 'q' // string literal or quoted identifier
+	bulkSQLTokenPlaceholder = 'p' // parameter marker ("$1", "?", "?1")
+)
+
+// bulkSQLToken is a lexical token of a SQL statement.
+type bulkSQLToken struct {
+	kind byte
+	// start and end are the byte offsets of the token in the statement
+	start int
+	end   int
+	// number is the parameter number of a placeholder.
+	// A "?" without a number is numbered one more than the largest number seen before it, as SQLite does.
+	number int
+}
+
+// bulkTokenizeSQL splits a SQL statement into tokens. Whitespace and comments are skipped.
+// With numberedPlaceholders, the statement is read as PostgreSQL: "$N" is a placeholder, "?" is an operator
+// and dollar-quoted strings are recognized.
+// Otherwise it is read as MySQL or SQLite: "?" and "?N" are placeholders, strings may contain backslash escapes
+// and "#" starts a comment.
+func bulkTokenizeSQL(query string, numberedPlaceholders bool) []bulkSQLToken {
+	isWordByte := func(c byte) bool {
+		return c == '_' || c == '$' || c >= 0x80 ||
+			('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9')
+	}
+	isDigit := func(c byte) bool { return '0' <= c && c <= '9' }
+	skipDigits := func(i int) int {
+		for i < len(query) && isDigit(query[i]) {
+			i++
+		}
+		return i
+	}
+
+	tokens := make([]bulkSQLToken, 0)
+	maxNumber := 0
+	for i := 0; i < len(query); {
+		c := query[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f':
+			i++
+		case strings.HasPrefix(query[i:], "--") || (c == '#' && !numberedPlaceholders):
+			if end := strings.IndexByte(query[i:], '\n'); end != -1 {
+				i += end + 1
+			} else {
+				i = len(query)
+			}
+		case strings.HasPrefix(query[i:], "/*"):
+			if end := strings.Index(query[i+2:], "*/"); end != -1 {
+				i += 2 + end + 2
+			} else {
+				i = len(query)
+			}
+		case c == '\'' || c == '"' || c == '`':
+			end := i + 1
+			for end < len(query) {
+				if query[end] == '\\' && c != '`' && !numberedPlaceholders {
+					end += 2
+					continue
+				}
+				if query[end] == c {
+					// A doubled quote is an escaped quote
+					if end+1 < len(query) && query[end+1] == c {
+						end += 2
+						continue
+					}
+					end++
+					break
+				}
+				end++
+			}
+			end = min(end, len(query))
+			tokens = append(tokens, bulkSQLToken{kind: bulkSQLTokenQuoted, start: i, end: end})
+			i = end
+		case c == '$' && numberedPlaceholders && i+1 < len(query) && isDigit(query[i+1]):
+			end := skipDigits(i + 1)
+			number, _ := strconv.Atoi(query[i+1 : end])
+			tokens = append(tokens, bulkSQLToken{kind: bulkSQLTokenPlaceholder, start: i, end: end, number: number})
+			i = end
+		case c == '$' && numberedPlaceholders:
+			// Dollar-quoted string: $$...$$ or $tag$...$tag$
+			end := i + 1
+			for end < len(query) && query[end] != '$' && isWordByte(query[end]) {
+				end++
+			}
+			if end >= len(query) || query[end] != '$' {
+				tokens = append(tokens, bulkSQLToken{kind: c, start: i, end: i + 1})
+				i++
+				continue
+			}
+			tag := query[i : end+1]
+			if closing := strings.Index(query[end+1:], tag); closing != -1 {
+				end += 1 + closing + len(tag)
+			} else {
+				end = len(query)
+			}
+			tokens = append(tokens, bulkSQLToken{kind: bulkSQLTokenQuoted, start: i, end: end})
+			i = end
+		case c == '?' && !numberedPlaceholders:
+			end := skipDigits(i + 1)
+			number := maxNumber + 1
+			if end > i+1 {
+				number, _ = strconv.Atoi(query[i+1 : end])
+			}
+			maxNumber = max(maxNumber, number)
+			tokens = append(tokens, bulkSQLToken{kind: bulkSQLTokenPlaceholder, start: i, end: end, number: number})
+			i = end
+		case isWordByte(c):
+			end := i + 1
+			for end < len(query) && isWordByte(query[end]) {
+				end++
+			}
+			tokens = append(tokens, bulkSQLToken{kind: bulkSQLTokenWord, start: i, end: end})
+			i = end
+		default:
+			tokens = append(tokens, bulkSQLToken{kind: c, start: i, end: i + 1})
+			i++
+		}
+	}
+	return tokens
 }
@@ -1,7 +1,16 @@
 package templates
 
 import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -125,9 +134,11 @@ func TestExtractFieldValues(t *testing.T) {
 func TestBuildBulkInsertQuery(t *testing.T) {
 	t.Parallel()
 	type Args struct {
-		originalQuery   string
-		numArgs         int
-		numParamsPerArg int
+		originalQuery        string
+		numArgs              int
+		numParamsPerArg      int
+		numStatementParams   int
+		numberedPlaceholders bool
 	}
 	type Expected struct {
 		query string
@@ -281,6 +292,178 @@ func TestBuildBulkInsertQuery(t *testing.T) {
 					}
 			},
 		},
+		"valid:Numbered placeholders": {
+			arrange: func(t *testing.T) (Args, Expected) {
+				return Args{
+						originalQuery:        "INSERT INTO users (id, name) VALUES ($1, $2);",
+						numArgs:              3,
+						numParamsPerArg:      2,
+						numberedPlaceholders: true,
+					}, Expected{
+						query: "INSERT INTO users (id, name) VALUES ($1,$2),($3,$4),($5,$6)",
+						err:   nil,
+					}
+			},
+		},
+		"valid:Numbered placeholders with ON CONFLICT and RETURNING": {
+			arrange: func(t *testing.T) (Args, Expected) {
+				return Args{
+						originalQuery:        "INSERT INTO users (id, name) VALUES ($1, $2) ON CONFLICT (id) DO NOTHING RETURNING id;",
+						numArgs:              2,
+						numParamsPerArg:      2,
+						numberedPlaceholders: true,
+					}, Expected{
+						query: "INSERT INTO users (id, name) VALUES ($1,$2),($3,$4) ON CONFLICT (id) DO NOTHING RETURNING id",
+						err:   nil,
+					}
+			},
+		},
+		"valid:Literals and function calls in VALUES": {
+			arrange: func(t *testing.T) (Args, Expected) {
+				return Args{
+						originalQuery:        "INSERT INTO events (id, created_at, kind) VALUES (?, NOW(), 'x');",
+						numArgs:              2,
+						numParamsPerArg:      1,
+						numberedPlaceholders: false,
+					}, Expected{
+						query: "INSERT INTO events (id, created_at, kind) VALUES (?,NOW(),'x'),(?,NOW(),'x')",
+						err:   nil,
+					}
+			},
+		},
+		"valid:Casts in VALUES": {
+			arrange: func(t *testing.T) (Args, Expected) {
+				return Args{
+						originalQuery:        "INSERT INTO events (id, name) VALUES ($1::uuid, $2);",
+						numArgs:              3,
+						numParamsPerArg:      2,
+						numberedPlaceholders: true,
+					}, Expected{
+						query: "INSERT INTO events (id, name) VALUES ($1::uuid,$2),($3::uuid,$4),($5::uuid,$6)",
+						err:   nil,
+					}
+			},
+		},
+		"valid:CAST expression in VALUES": {
+			arrange: func(t *testing.T) (Args, Expected) {
+				return Args{
+						originalQuery:        "INSERT INTO events (id, at) VALUES ($1, CAST($2 AS timestamptz));",
+						numArgs:              2,
+						numParamsPerArg:      2,
+						numberedPlaceholders: true,
+					}, Expected{
+						query: "INSERT INTO events (id, at) VALUES ($1,CAST($2 AS timestamptz)),($3,CAST($4 AS timestamptz))",
+						err:   nil,
+					}
+			},
+		},
+		"valid:Same parameter used twice in a row": {
+			arrange: func(t *testing.T) (Args, Expected) {
+				return Args{
+						originalQuery:        "INSERT INTO users (id, name, display_name) VALUES ($1, $2, $2);",
+						numArgs:              2,
+						numParamsPerArg:      2,
+						numberedPlaceholders: true,
+					}, Expected{
+						query: "INSERT INTO users (id, name, display_name) VALUES ($1,$2,$2),($3,$4,$4)",
+						err:   nil,
+					}
+			},
+		},
+		"valid:Placeholder-like text in string literals and comments": {
+			arrange: func(t *testing.T) (Args, Expected) {
+				return Args{
+						originalQuery:        "INSERT INTO notes (id, body) VALUES (?, 'VALUES (?, ?)') -- VALUES (?)\n;",
+						numArgs:              2,
+						numParamsPerArg:      1,
+						numberedPlaceholders: false,
+					}, Expected{
+						query: "INSERT INTO notes (id, body) VALUES (?,'VALUES (?, ?)'),(?,'VALUES (?, ?)')",
+						err:   nil,
+					}
+			},
+		},
+		"valid:Escaped quotes in string literals": {
+			arrange: func(t *testing.T) (Args, Expected) {
+				return Args{
+						originalQuery:        "INSERT INTO notes (id, body) VALUES (?, 'it''s \\' ?');",
+						numArgs:              2,
+						numParamsPerArg:      1,
+						numberedPlaceholders: false,
+					}, Expected{
+						query: "INSERT INTO notes (id, body) VALUES (?,'it''s \\' ?'),(?,'it''s \\' ?')",
+						err:   nil,
+					}
+			},
+		},
+		"valid:PostgreSQL question mark operator and dollar-quoted string": {
+			arrange: func(t *testing.T) (Args, Expected) {
+				return Args{
+						originalQuery:        "INSERT INTO docs (id, has_key, note) VALUES ($1, $2::jsonb ? 'key', $$it's $3$$);",
+						numArgs:              2,
+						numParamsPerArg:      2,
+						numberedPlaceholders: true,
+					}, Expected{
+						query: "INSERT INTO docs (id, has_key, note) VALUES ($1,$2::jsonb ? 'key',$$it's $3$$),($3,$4::jsonb ? 'key',$$it's $3$$)",
+						err:   nil,
+					}
+			},
+		},
+		"valid:SQLite numbered placeholders": {
+			arrange: func(t *testing.T) (Args, Expected) {
+				return Args{
+						originalQuery:        "INSERT INTO users (id, name) VALUES (?1, ?2);",
+						numArgs:              2,
+						numParamsPerArg:      2,
+						numberedPlaceholders: false,
+					}, Expected{
+						query: "INSERT INTO users (id, name) VALUES (?1,?2),(?3,?4)",
+						err:   nil,
+					}
+			},
+		},
+		"valid:Statement-level parameter in ON CONFLICT": {
+			arrange: func(t *testing.T) (Args, Expected) {
+				return Args{
+						originalQuery:        "INSERT INTO users (id, name) VALUES ($1, $2) ON CONFLICT (id) DO UPDATE SET name = EXCLUDED.name, updated_by = $3;",
+						numArgs:              2,
+						numParamsPerArg:      2,
+						numStatementParams:   1,
+						numberedPlaceholders: true,
+					}, Expected{
+						query: "INSERT INTO users (id, name) VALUES ($1,$2),($3,$4) ON CONFLICT (id) DO UPDATE SET name = EXCLUDED.name, updated_by = $5",
+						err:   nil,
+					}
+			},
+		},
+		"valid:Statement-level parameters numbered before the row": {
+			arrange: func(t *testing.T) (Args, Expected) {
+				return Args{
+						originalQuery:        "INSERT INTO users (id, name) VALUES ($2, $3) ON CONFLICT (id) DO UPDATE SET updated_by = $1, updated_at = $4 WHERE users.updated_by <> $1;",
+						numArgs:              2,
+						numParamsPerArg:      2,
+						numStatementParams:   2,
+						numberedPlaceholders: true,
+					}, Expected{
+						query: "INSERT INTO users (id, name) VALUES ($1,$2),($3,$4) ON CONFLICT (id) DO UPDATE SET updated_by = $5, updated_at = $6 WHERE users.updated_by <> $5",
+						err:   nil,
+					}
+			},
+		},
+		"valid:Statement-level parameter in ON DUPLICATE KEY UPDATE": {
+			arrange: func(t *testing.T) (Args, Expected) {
+				return Args{
+						originalQuery:        "INSERT INTO users (id, name) VALUES (?, ?) ON DUPLICATE KEY UPDATE name = VALUES(name), updated_by = ?;",
+						numArgs:              2,
+						numParamsPerArg:      2,
+						numStatementParams:   1,
+						numberedPlaceholders: false,
+					}, Expected{
+						query: "INSERT INTO users (id, name) VALUES (?,?),(?,?) ON DUPLICATE KEY UPDATE name = VALUES(name), updated_by = ?",
+						err:   nil,
+					}
+			},
+		},
 		"error: numArgs is zero": {
 			arrange: func(t *testing.T) (Args, Expected) {
 				return Args{
@@ -317,13 +500,198 @@ func TestBuildBulkInsertQuery(t *testing.T) {
 					}
 			},
 		},
+		"error: parameter count mismatch": {
+			arrange: func(t *testing.T) (Args, Expected) {
+				return Args{
+						originalQuery:        "INSERT INTO events (id, created_at) VALUES (?, NOW());",
+						numArgs:              3,
+						numParamsPerArg:      2,
+						numberedPlaceholders: false,
+					}, Expected{
+						query: "",
+						err:   errors.New("VALUES clause has 1 parameters but 2 parameters per argument (columns) were given"),
+					}
+			},
+		},
+		"error: multiple rows in VALUES": {
+			arrange: func(t *testing.T) (Args, Expected) {
+				return Args{
+						originalQuery:        "INSERT INTO users (id, name) VALUES (?, ?), (?, ?);",
+						numArgs:              3,
+						numParamsPerArg:      2,
+						numberedPlaceholders: false,
+					}, Expected{
+						query: "",
+						err:   errors.New("VALUES clause has more than one row"),
+					}
+			},
+		},
+		"error: unbalanced parentheses": {
+			arrange: func(t *testing.T) (Args, Expected) {
+				return Args{
+						originalQuery:        "INSERT INTO users (id, name) VALUES (?, NOW(;",
+						numArgs:              3,
+						numParamsPerArg:      1,
+						numberedPlaceholders: false,
+					}, Expected{
+						query: "",
+						err:   errors.New("unbalanced parentheses"),
+					}
+			},
+		},
+		"error: statement-level parameter count mismatch": {
+			arrange: func(t *testing.T) (Args, Expected) {
+				return Args{
+						originalQuery:        "INSERT INTO users (id, name) VALUES ($1, $2) ON CONFLICT (id) DO UPDATE SET updated_by = $3;",
+						numArgs:              2,
+						numParamsPerArg:      2,
+						numStatementParams:   0,
+						numberedPlaceholders: true,
+					}, Expected{
+						query: "",
+						err:   errors.New("query has 1 statement-level parameters but 0 were given"),
+					}
+			},
+		},
+		"error: parameter used in and outside VALUES": {
+			arrange: func(t *testing.T) (Args, Expected) {
+				return Args{
+						originalQuery:        "INSERT INTO users (id, name) VALUES ($1, $2) ON CONFLICT (id) DO UPDATE SET name = $2;",
+						numArgs:              2,
+						numParamsPerArg:      2,
+						numStatementParams:   0,
+						numberedPlaceholders: true,
+					}, Expected{
+						query: "",
+						err:   errors.New("parameter 2 is used both in and outside the VALUES clause"),
+					}
+			},
+		},
+		"error: parameter before VALUES": {
+			arrange: func(t *testing.T) (Args, Expected) {
+				return Args{
+						originalQuery:        "WITH v AS (SELECT $1::text AS name) INSERT INTO users (id, name) VALUES ($2, $3);",
+						numArgs:              2,
+						numParamsPerArg:      2,
+						numStatementParams:   0,
+						numberedPlaceholders: true,
+					}, Expected{
+						query: "",
+						err:   errors.New("parameters before the VALUES clause are not supported"),
+					}
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			arg, expected := tc.arrange(t)
+			result, err := buildBulkInsertQuery(
+				arg.originalQuery, arg.numArgs, arg.numParamsPerArg, arg.numStatementParams, arg.numberedPlaceholders)
+			if expected.err != nil {
+				assert.ErrorContains(t, err, expected.err.Error())
+				return
+			}
+			assert.NilError(t, err)
+			assert.Equal(t, result, expected.query)
+		})
+	}
+}
+
+func TestBuildOrderedBulkInsertQuery(t *testing.T) {
+	t.Parallel()
+	type Args struct {
+		originalQuery      string
+		numArgs            int
+		numParamsPerArg    int
+		numStatementParams int
+	}
+	type Expected struct {
+		query string
+		err   error
+	}
+
+	tests := map[string]struct {
+		arrange func(*testing.T) (Args, Expected)
+	}{
+		"valid:Standard": {
+			arrange: func(t *testing.T) (Args, Expected) {
+				return Args{
+						originalQuery:   "INSERT INTO users (id, name) VALUES ($1, $2) RETURNING id;",
+						numArgs:         2,
+						numParamsPerArg: 2,
+					}, Expected{
+						query: "WITH bulk_0 AS (INSERT INTO users (id, name) VALUES ($1,$2) RETURNING id), " +
+							"bulk_1 AS (INSERT INTO users (id, name) VALUES ($3,$4) RETURNING id) " +
+							"SELECT 0, * FROM bulk_0 UNION ALL SELECT 1, * FROM bulk_1",
+						err: nil,
+					}
+			},
+		},
+		"valid:single row": {
+			arrange: func(t *testing.T) (Args, Expected) {
+				return Args{
+						originalQuery:   "INSERT INTO users (id, name) VALUES ($1, now()) RETURNING *",
+						numArgs:         1,
+						numParamsPerArg: 1,
+					}, Expected{
+						query: "WITH bulk_0 AS (INSERT INTO users (id, name) VALUES ($1,now()) RETURNING *) " +
+							"SELECT 0, * FROM bulk_0",
+						err: nil,
+					}
+			},
+		},
+		"valid:statement-level parameters are shared by all rows": {
+			arrange: func(t *testing.T) (Args, Expected) {
+				return Args{
+						originalQuery: "INSERT INTO users (id, name) VALUES ($1, $2) " +
+							"ON CONFLICT (id) DO UPDATE SET updated_by = $3 RETURNING id, name",
+						numArgs:            2,
+						numParamsPerArg:    2,
+						numStatementParams: 1,
+					}, Expected{
+						query: "WITH bulk_0 AS (INSERT INTO users (id, name) VALUES ($1,$2) " +
+							"ON CONFLICT (id) DO UPDATE SET updated_by = $5 RETURNING id, name), " +
+							"bulk_1 AS (INSERT INTO users (id, name) VALUES ($3,$4) " +
+							"ON CONFLICT (id) DO UPDATE SET updated_by = $5 RETURNING id, name) " +
+							"SELECT 0, * FROM bulk_0 UNION ALL SELECT 1, * FROM bulk_1",
+						err: nil,
+					}
+			},
+		},
+		"error: zero arguments": {
+			arrange: func(t *testing.T) (Args, Expected) {
+				return Args{
+						originalQuery:   "INSERT INTO users (id, name) VALUES ($1, $2) RETURNING id",
+						numArgs:         0,
+						numParamsPerArg: 2,
+					}, Expected{
+						query: "",
+						err:   errors.New("number of arguments (rows) for bulk insert cannot be zero"),
+					}
+			},
+		},
+		"error: mismatched number of parameters per argument": {
+			arrange: func(t *testing.T) (Args, Expected) {
+				return Args{
+						originalQuery:   "INSERT INTO users (id, name) VALUES ($1, $2) RETURNING id",
+						numArgs:         2,
+						numParamsPerArg: 3,
+					}, Expected{
+						query: "",
+						err:   errors.New("VALUES clause has 2 parameters but 3 parameters per argument (columns) were given"),
+					}
+			},
+		},
 	}
 
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
 			t.Parallel()
 			arg, expected := tc.arrange(t)
-			result, err := buildBulkInsertQuery(arg.originalQuery, arg.numArgs, arg.numParamsPerArg)
+			result, err := buildOrderedBulkInsertQuery(
+				arg.originalQuery, arg.numArgs, arg.numParamsPerArg, arg.numStatementParams)
 			if expected.err != nil {
 				assert.ErrorContains(t, err, expected.err.Error())
 				return
@@ -333,3 +701,845 @@ func TestBuildBulkInsertQuery(t *testing.T) {
 		})
 	}
 }
+
+func TestBulkChunkSize(t *testing.T) {
+	t.Parallel()
+	type Args struct {
+		numParamsPerArg    int
+		numStatementParams int
+		maxPlaceholders    int
+		batchSize          int
+	}
+	type Expected struct {
+		chunkSize int
+		err       error
+	}
+
+	tests := map[string]struct {
+		arrange func(*testing.T) (Args, Expected)
+	}{
+		"placeholder limit": {
+			arrange: func(t *testing.T) (Args, Expected) {
+				return Args{
+						numParamsPerArg:    3,
+						numStatementParams: 0,
+						maxPlaceholders:    65535,
+						batchSize:          0,
+					}, Expected{
+						chunkSize: 21845,
+						err:       nil,
+					}
+			},
+		},
+		"placeholder limit with statement-level parameters": {
+			arrange: func(t *testing.T) (Args, Expected) {
+				return Args{
+						numParamsPerArg:    2,
+						numStatementParams: 2,
+						maxPlaceholders:    32766,
+						batchSize:          0,
+					}, Expected{
+						chunkSize: 16382,
+						err:       nil,
+					}
+			},
+		},
+		"batch size": {
+			arrange: func(t *testing.T) (Args, Expected) {
+				return Args{
+						numParamsPerArg:    3,
+						numStatementParams: 0,
+						maxPlaceholders:    65535,
+						batchSize:          1000,
+					}, Expected{
+						chunkSize: 1000,
+						err:       nil,
+					}
+			},
+		},
+		"batch size above the placeholder limit": {
+			arrange: func(t *testing.T) (Args, Expected) {
+				return Args{
+						numParamsPerArg:    3,
+						numStatementParams: 0,
+						maxPlaceholders:    65535,
+						batchSize:          100000,
+					}, Expected{
+						chunkSize: 21845,
+						err:       nil,
+					}
+			},
+		},
+		"error: zero parameters per argument": {
+			arrange: func(t *testing.T) (Args, Expected) {
+				return Args{
+						numParamsPerArg:    0,
+						numStatementParams: 0,
+						maxPlaceholders:    65535,
+						batchSize:          0,
+					}, Expected{
+						chunkSize: 0,
+						err:       errors.New("number of parameters per argument (columns) for bulk insert must be positive"),
+					}
+			},
+		},
+		"error: a row exceeds the placeholder limit": {
+			arrange: func(t *testing.T) (Args, Expected) {
+				return Args{
+						numParamsPerArg:    3,
+						numStatementParams: 1,
+						maxPlaceholders:    3,
+						batchSize:          0,
+					}, Expected{
+						chunkSize: 0,
+						err:       errors.New("a row with 3 parameters and 1 statement-level parameters exceeds the limit of 3 placeholders"),
+					}
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			arg, expected := tc.arrange(t)
+			result, err := bulkChunkSize(arg.numParamsPerArg, arg.numStatementParams, arg.maxPlaceholders, arg.batchSize)
+			if expected.err != nil {
+				assert.ErrorContains(t, err, expected.err.Error())
+				return
+			}
+			assert.NilError(t, err)
+			assert.Equal(t, result, expected.chunkSize)
+		})
+	}
+}
+
+func TestBulkChunkEnds(t *testing.T) {
+	t.Parallel()
+	type Args struct {
+		originalQuery   string
+		preparedValues  []any
+		statementValues []any
+		numParamsPerArg int
+		chunkSize       int
+		maxBytes        int
+	}
+	type Expected struct {
+		chunkEnds []int
+	}
+
+	const originalQuery = "INSERT INTO t (id, body) VALUES (?, ?)" // 38 bytes
+	tests := map[string]struct {
+		arrange func(*testing.T) (Args, Expected)
+	}{
+		"no rows": {
+			arrange: func(t *testing.T) (Args, Expected) {
+				return Args{
+						originalQuery:   originalQuery,
+						preparedValues:  []any{},
+						statementValues: nil,
+						numParamsPerArg: 2,
+						chunkSize:       2,
+						maxBytes:        0,
+					}, Expected{
+						chunkEnds: nil,
+					}
+			},
+		},
+		"chunk size": {
+			arrange: func(t *testing.T) (Args, Expected) {
+				return Args{
+						originalQuery:   originalQuery,
+						preparedValues:  []any{1, "a", 2, "b", 3, "c", 4, "d", 5, "e"},
+						statementValues: nil,
+						numParamsPerArg: 2,
+						chunkSize:       2,
+						maxBytes:        0,
+					}, Expected{
+						chunkEnds: []int{2, 4, 5},
+					}
+			},
+		},
+		"single chunk": {
+			arrange: func(t *testing.T) (Args, Expected) {
+				return Args{
+						originalQuery:   originalQuery,
+						preparedValues:  []any{1, "a", 2, "b"},
+						statementValues: nil,
+						numParamsPerArg: 2,
+						chunkSize:       100,
+						maxBytes:        0,
+					}, Expected{
+						chunkEnds: []int{2},
+					}
+			},
+		},
+		"byte budget": {
+			arrange: func(t *testing.T) (Args, Expected) {
+				// Each row is estimated at 1 + 8 (id) + 52 + 8 (body) = 69 bytes
+				body := strings.Repeat("x", 50)
+				return Args{
+						originalQuery:   originalQuery,
+						preparedValues:  []any{1, body, 2, body, 3, body, 4, body, 5, body},
+						statementValues: nil,
+						numParamsPerArg: 2,
+						chunkSize:       100,
+						maxBytes:        38 + 69*2,
+					}, Expected{
+						chunkEnds: []int{2, 4, 5},
+					}
+			},
+		},
+		"byte budget with statement-level values": {
+			arrange: func(t *testing.T) (Args, Expected) {
+				body := strings.Repeat("x", 50)
+				return Args{
+						originalQuery:   originalQuery,
+						preparedValues:  []any{1, body, 2, body, 3, body},
+						statementValues: []any{"updater"},
+						numParamsPerArg: 2,
+						chunkSize:       100,
+						maxBytes:        38 + 69*2,
+					}, Expected{
+						chunkEnds: []int{1, 2, 3},
+					}
+			},
+		},
+		"chunk size within the byte budget": {
+			arrange: func(t *testing.T) (Args, Expected) {
+				return Args{
+						originalQuery:   originalQuery,
+						preparedValues:  []any{1, "a", 2, "b", 3, "c"},
+						statementValues: nil,
+						numParamsPerArg: 2,
+						chunkSize:       1,
+						maxBytes:        1 << 20,
+					}, Expected{
+						chunkEnds: []int{1, 2, 3},
+					}
+			},
+		},
+		"row larger than the byte budget": {
+			arrange: func(t *testing.T) (Args, Expected) {
+				return Args{
+						originalQuery:   originalQuery,
+						preparedValues:  []any{1, "a", 2, strings.Repeat("x", 1000), 3, "c"},
+						statementValues: nil,
+						numParamsPerArg: 2,
+						chunkSize:       100,
+						maxBytes:        200,
+					}, Expected{
+						chunkEnds: []int{1, 2, 3},
+					}
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			arg, expected := tc.arrange(t)
+			result := bulkChunkEnds(
+				arg.originalQuery, arg.preparedValues, arg.statementValues, arg.numParamsPerArg, arg.chunkSize, arg.maxBytes)
+			assert.DeepEqual(t, result, expected.chunkEnds)
+		})
+	}
+}
+
+func TestBulkEstimateValueSize(t *testing.T) {
+	t.Parallel()
+	text := "hello"
+	var nilText *string
+	tests := map[string]struct {
+		value any
+		want  int
+	}{
+		"nil":             {value: nil, want: 4},
+		"string":          {value: "hello", want: 7},
+		"pointer":         {value: &text, want: 7},
+		"nil pointer":     {value: nilText, want: 4},
+		"bytes":           {value: []byte{1, 2, 3}, want: 9},
+		"named bytes":     {value: json.RawMessage(`{}`), want: 7},
+		"int":             {value: int64(-12345), want: 6},
+		"uint":            {value: uint8(255), want: 3},
+		"bool":            {value: true, want: 5},
+		"float":           {value: 3.5, want: 3},
+		"valuer":          {value: sql.NullString{String: "hello", Valid: true}, want: 7},
+		"null valuer":     {value: sql.NullString{}, want: 4},
+		"valuer of bytes": {value: sql.Null[[]byte]{V: []byte{1}, Valid: true}, want: 5},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, bulkEstimateValueSize(tt.value), tt.want)
+		})
+	}
+}
+
+func TestBulkLoadDataLocalInfile(t *testing.T) {
+	t.Parallel()
+	type Row struct {
+		ID        int64
+		Name      string
+		Note      *string
+		Data      []byte
+		Active    bool
+		Score     sql.NullFloat64
+		CreatedAt time.Time
+	}
+	note := "a\tb\nc\\d\x00\\N"
+	createdAt := time.Date(2024, 1, 2, 12, 34, 56, 789000000, time.FixedZone("JST", 9*60*60))
+	rows := []Row{
+		{ID: 1, Name: "alice", Note: &note, Data: []byte("\r"), Active: true, Score: sql.NullFloat64{Float64: 1.5, Valid: true}, CreatedAt: createdAt},
+		{ID: 2, Name: "bob", CreatedAt: createdAt},
+	}
+	type Args struct {
+		table           []string
+		columns         []string
+		paramFieldNames []string
+	}
+	type Expected struct {
+		query        string
+		data         string
+		rowsAffected int64
+		err          error
+	}
+
+	tests := map[string]struct {
+		args     Args
+		expected Expected
+	}{
+		"columns in the order of paramFieldNames": {
+			args: Args{
+				table:           []string{"users"},
+				columns:         []string{"name", "id", "note", "data", "active", "score", "created_at"},
+				paramFieldNames: []string{"Name", "ID", "Note", "Data", "Active", "Score", "CreatedAt"},
+			},
+			expected: Expected{
+				query: "LOAD DATA LOCAL INFILE 'Reader::%s' INTO TABLE `users` CHARACTER SET utf8mb4" +
+					" FIELDS TERMINATED BY '\\t' ESCAPED BY '\\\\' LINES TERMINATED BY '\\n'" +
+					" (`name`, `id`, `note`, `data`, `active`, `score`, `created_at`)",
+				data: "alice\t1\ta\\tb\\nc\\\\d\\0\\\\N\t\\r\t1\t1.5\t2024-01-02 03:34:56.789\n" +
+					"bob\t2\t\\N\t\\N\t0\t\\N\t2024-01-02 03:34:56.789\n",
+				rowsAffected: 2,
+			},
+		},
+		"quoted table of a schema": {
+			args: Args{
+				table:           []string{"app", "user`s"},
+				columns:         []string{"id"},
+				paramFieldNames: []string{"ID"},
+			},
+			expected: Expected{
+				query: "LOAD DATA LOCAL INFILE 'Reader::%s' INTO TABLE `app`.`user``s` CHARACTER SET utf8mb4" +
+					" FIELDS TERMINATED BY '\\t' ESCAPED BY '\\\\' LINES TERMINATED BY '\\n' (`id`)",
+				data:         "1\n2\n",
+				rowsAffected: 2,
+			},
+		},
+		"error: columns do not match the values": {
+			args: Args{
+				table:           []string{"users"},
+				columns:         []string{"id", "name", "note"},
+				paramFieldNames: []string{"ID", "Name"},
+			},
+			expected: Expected{err: errors.New("4 values cannot be split into rows of 3 columns")},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			connector := &fakeLoadDataConnector{handlers: make(map[string]func() io.Reader)}
+			db := sql.OpenDB(connector)
+			t.Cleanup(func() { db.Close() })
+			values, err := extractFieldValues(rows, tc.args.paramFieldNames)
+			assert.NilError(t, err)
+
+			rowsAffected, err := bulkLoadDataLocalInfile(t.Context(), db, tc.args.table, tc.args.columns, values,
+				connector.register, connector.deregister)
+			assert.Equal(t, len(connector.handlers), 0)
+			if tc.expected.err != nil {
+				assert.ErrorContains(t, err, tc.expected.err.Error())
+				return
+			}
+			assert.NilError(t, err)
+			assert.Equal(t, connector.query, fmt.Sprintf(tc.expected.query, connector.name))
+			assert.Equal(t, connector.data, tc.expected.data)
+			assert.Equal(t, rowsAffected, tc.expected.rowsAffected)
+		})
+	}
+
+	t.Run("error: unsupported value", func(t *testing.T) {
+		t.Parallel()
+		connector := &fakeLoadDataConnector{handlers: make(map[string]func() io.Reader)}
+		db := sql.OpenDB(connector)
+		t.Cleanup(func() { db.Close() })
+
+		_, err := bulkLoadDataLocalInfile(t.Context(), db, []string{"users"}, []string{"id", "tags"},
+			[]any{1, "a", 2, []string{"b"}}, connector.register, connector.deregister)
+		assert.ErrorContains(t, err, "args[1]: column 1:")
+		assert.Equal(t, len(connector.handlers), 0)
+	})
+}
+
+// fakeLoadDataConnector is a fake MySQL driver that runs LOAD DATA LOCAL INFILE
+// by reading the rows from the registered reader handler, as github.com/go-sql-driver/mysql does.
+type fakeLoadDataConnector struct {
+	mu       sync.Mutex
+	handlers map[string]func() io.Reader
+	// name, query and data are the handler name, the statement and the rows of the last LOAD DATA
+	name  string
+	query string
+	data  string
+}
+
+func (c *fakeLoadDataConnector) register(name string, handler func() io.Reader) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.handlers[name] = handler
+}
+
+func (c *fakeLoadDataConnector) deregister(name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.handlers, name)
+}
+
+func (c *fakeLoadDataConnector) Connect(context.Context) (driver.Conn, error) {
+	return &fakeLoadDataConn{connector: c}, nil
+}
+
+func (c *fakeLoadDataConnector) Driver() driver.Driver {
+	return nil
+}
+
+type fakeLoadDataConn struct {
+	connector *fakeLoadDataConnector
+}
+
+func (c *fakeLoadDataConn) ExecContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Result, error) {
+	_, rest, ok := strings.Cut(query, "'Reader::")
+	if !ok {
+		return nil, fmt.Errorf("not a LOAD DATA statement: %s", query)
+	}
+	name, _, _ := strings.Cut(rest, "'")
+	c.connector.mu.Lock()
+	handler, ok := c.connector.handlers[name]
+	c.connector.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("reader handler %q is not registered", name)
+	}
+	reader := handler()
+	if closer, ok := reader.(io.Closer); ok {
+		defer closer.Close()
+	}
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	c.connector.name, c.connector.query, c.connector.data = name, query, string(data)
+	return driver.RowsAffected(strings.Count(string(data), "\n")), nil
+}
+
+func (c *fakeLoadDataConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (c *fakeLoadDataConn) Close() error {
+	return nil
+}
+
+func (c *fakeLoadDataConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("not implemented")
+}
+
+func TestBulkInsertSeq(t *testing.T) {
+	t.Parallel()
+	errSeq := errors.New("seq error")
+	errFlush := errors.New("flush error")
+	type Args struct {
+		rows      []int
+		seqErrAt  int // index of rows at which seq yields errSeq, or -1
+		chunkSize int
+		flushErr  int // number of the flush that fails, or 0
+		cancelAt  int // index of rows before which ctx is canceled, or -1
+	}
+	type Expected struct {
+		chunks [][]int
+		total  int64
+		pulled int
+		err    error
+	}
+
+	tests := map[string]struct {
+		args     Args
+		expected Expected
+	}{
+		"chunks and the rest": {
+			args:     Args{rows: []int{1, 2, 3, 4, 5}, seqErrAt: -1, chunkSize: 2, cancelAt: -1},
+			expected: Expected{chunks: [][]int{{1, 2}, {3, 4}, {5}}, total: 5, pulled: 5},
+		},
+		"exact chunks": {
+			args:     Args{rows: []int{1, 2, 3, 4}, seqErrAt: -1, chunkSize: 2, cancelAt: -1},
+			expected: Expected{chunks: [][]int{{1, 2}, {3, 4}}, total: 4, pulled: 4},
+		},
+		"empty": {
+			args:     Args{rows: nil, seqErrAt: -1, chunkSize: 2, cancelAt: -1},
+			expected: Expected{chunks: nil, total: 0, pulled: 0},
+		},
+		"error: seq": {
+			args:     Args{rows: []int{1, 2, 3, 4, 5}, seqErrAt: 3, chunkSize: 2, cancelAt: -1},
+			expected: Expected{chunks: [][]int{{1, 2}}, total: 2, pulled: 4, err: errSeq},
+		},
+		"error: flush": {
+			args:     Args{rows: []int{1, 2, 3, 4, 5}, seqErrAt: -1, chunkSize: 2, flushErr: 2, cancelAt: -1},
+			expected: Expected{chunks: [][]int{{1, 2}, {3, 4}}, total: 2, pulled: 4, err: errFlush},
+		},
+		"error: context canceled": {
+			args:     Args{rows: []int{1, 2, 3, 4, 5}, seqErrAt: -1, chunkSize: 2, cancelAt: 3},
+			expected: Expected{chunks: [][]int{{1, 2}}, total: 2, pulled: 4, err: context.Canceled},
+		},
+		"error: context canceled after the last row": {
+			args:     Args{rows: []int{1, 2, 3}, seqErrAt: -1, chunkSize: 2, cancelAt: 3},
+			expected: Expected{chunks: [][]int{{1, 2}}, total: 2, pulled: 3, err: context.Canceled},
+		},
+		"error: chunk size": {
+			args:     Args{rows: []int{1}, seqErrAt: -1, chunkSize: 0, cancelAt: -1},
+			expected: Expected{err: errors.New("chunk size must be positive: 0")},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ctx, cancel := context.WithCancel(t.Context())
+			defer cancel()
+			pulled := 0
+			seq := func(yield func(int, error) bool) {
+				for i, row := range tc.args.rows {
+					if i == tc.args.cancelAt {
+						cancel()
+					}
+					pulled++
+					if i == tc.args.seqErrAt {
+						yield(0, errSeq)
+						return
+					}
+					if !yield(row, nil) {
+						return
+					}
+				}
+				if len(tc.args.rows) == tc.args.cancelAt {
+					cancel()
+				}
+			}
+			var chunks [][]int
+			flush := func(rows []int) error {
+				chunks = append(chunks, slices.Clone(rows))
+				if len(chunks) == tc.args.flushErr {
+					return errFlush
+				}
+				return nil
+			}
+
+			total, err := bulkInsertSeq(ctx, seq, tc.args.chunkSize, flush)
+			if tc.expected.err != nil {
+				assert.ErrorContains(t, err, tc.expected.err.Error())
+			} else {
+				assert.NilError(t, err)
+			}
+			assert.Equal(t, total, tc.expected.total)
+			assert.DeepEqual(t, chunks, tc.expected.chunks)
+			assert.Equal(t, pulled, tc.expected.pulled)
+		})
+	}
+}
+
+func TestBulkSeq2(t *testing.T) {
+	t.Parallel()
+	var got []int
+	for v, err := range bulkSeq2(slices.Values([]int{1, 2, 3})) {
+		assert.NilError(t, err)
+		got = append(got, v)
+		if v == 2 {
+			break
+		}
+	}
+	assert.DeepEqual(t, got, []int{1, 2})
+}
+
+func TestBulkWriter(t *testing.T) {
+	t.Parallel()
+	errFlush := errors.New("flush error")
+	type step struct {
+		op   string // "add", "flush" or "close"
+		row  int
+		fail bool // flush fails in this step
+		err  string
+	}
+	tests := map[string]struct {
+		size     int
+		steps    []step
+		expected [][]int
+	}{
+		"flush at size": {
+			size: 2,
+			steps: []step{
+				{op: "add", row: 1}, {op: "add", row: 2}, {op: "add", row: 3},
+			},
+			expected: [][]int{{1, 2}},
+		},
+		"explicit flush": {
+			size: 3,
+			steps: []step{
+				{op: "add", row: 1}, {op: "flush"}, {op: "flush"}, {op: "add", row: 2},
+			},
+			expected: [][]int{{1}},
+		},
+		"close flushes the rest": {
+			size: 2,
+			steps: []step{
+				{op: "add", row: 1}, {op: "add", row: 2}, {op: "add", row: 3}, {op: "close"}, {op: "close"},
+			},
+			expected: [][]int{{1, 2}, {3}},
+		},
+		"rows stay buffered when the flush fails": {
+			size: 2,
+			steps: []step{
+				{op: "add", row: 1}, {op: "add", row: 2, fail: true, err: "flush error"}, {op: "add", row: 3},
+			},
+			expected: [][]int{{1, 2}, {1, 2, 3}},
+		},
+		"close can be retried": {
+			size: 2,
+			steps: []step{
+				{op: "add", row: 1}, {op: "close", fail: true, err: "flush error"}, {op: "add", row: 2}, {op: "close"},
+			},
+			expected: [][]int{{1}, {1, 2}},
+		},
+		"error: closed": {
+			size: 2,
+			steps: []step{
+				{op: "close"}, {op: "add", row: 1, err: "bulk writer is closed"}, {op: "flush", err: "bulk writer is closed"},
+			},
+			expected: nil,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			var flushed [][]int
+			fail := false
+			w := newBulkWriter(tc.size, func(ctx context.Context, rows []int) error {
+				flushed = append(flushed, slices.Clone(rows))
+				if fail {
+					return errFlush
+				}
+				return nil
+			})
+			for i, s := range tc.steps {
+				fail = s.fail
+				var err error
+				switch s.op {
+				case "add":
+					err = w.add(t.Context(), s.row)
+				case "flush":
+					err = w.flushRows(t.Context())
+				case "close":
+					err = w.close(t.Context())
+				}
+				if s.err != "" {
+					assert.ErrorContains(t, err, s.err, "step %d", i)
+				} else {
+					assert.NilError(t, err, "step %d", i)
+				}
+			}
+			assert.DeepEqual(t, flushed, tc.expected)
+		})
+	}
+}
+
+// fakeTicker is a ticker of bulkAsyncWriter that ticks only when the test sends to tick.
+type fakeTicker struct {
+	tick     chan time.Time
+	interval time.Duration
+	stopped  chan struct{}
+}
+
+func newFakeTicker() *fakeTicker {
+	// tick is unbuffered, so that a send returns only after the writer received it
+	return &fakeTicker{tick: make(chan time.Time), stopped: make(chan struct{})}
+}
+
+func (f *fakeTicker) newTicker(d time.Duration) (<-chan time.Time, func()) {
+	f.interval = d
+	return f.tick, func() { close(f.stopped) }
+}
+
+func TestBulkAsyncWriter(t *testing.T) {
+	t.Parallel()
+	type flushed struct {
+		rows []int
+		err  error
+	}
+	// newWriter starts a writer with a fake ticker and no queue, so that add returns only after the writer received the row.
+	// Each flush is sent to the returned channel and fails with the error received from failures, if any.
+	newWriter := func(t *testing.T, size int) (*bulkAsyncWriter[int], *fakeTicker, chan flushed, chan error, chan flushed) {
+		ticker := newFakeTicker()
+		flushes := make(chan flushed, 10)
+		failures := make(chan error, 10)
+		errorCalls := make(chan flushed, 10)
+		w := newBulkAsyncWriter(t.Context(), size, time.Second, 0,
+			func(ctx context.Context, rows []int) error {
+				var err error
+				select {
+				case err = <-failures:
+				default:
+				}
+				flushes <- flushed{rows: slices.Clone(rows), err: err}
+				return err
+			},
+			func(err error, rows []int) { errorCalls <- flushed{rows: rows, err: err} },
+			ticker.newTicker,
+		)
+		assert.Equal(t, ticker.interval, time.Second)
+		return w, ticker, flushes, failures, errorCalls
+	}
+
+	t.Run("flush at size", func(t *testing.T) {
+		t.Parallel()
+		w, _, flushes, _, _ := newWriter(t, 2)
+		for _, row := range []int{1, 2, 3} {
+			assert.NilError(t, w.add(t.Context(), row))
+		}
+		assert.DeepEqual(t, (<-flushes).rows, []int{1, 2})
+		assert.NilError(t, w.close(t.Context()))
+		assert.DeepEqual(t, (<-flushes).rows, []int{3})
+	})
+
+	t.Run("flush at tick", func(t *testing.T) {
+		t.Parallel()
+		w, ticker, flushes, _, _ := newWriter(t, 10)
+		// No flush without rows
+		ticker.tick <- time.Time{}
+		assert.NilError(t, w.add(t.Context(), 1))
+		ticker.tick <- time.Time{}
+		assert.DeepEqual(t, (<-flushes).rows, []int{1})
+		assert.NilError(t, w.add(t.Context(), 2))
+		assert.NilError(t, w.add(t.Context(), 3))
+		ticker.tick <- time.Time{}
+		assert.DeepEqual(t, (<-flushes).rows, []int{2, 3})
+		assert.NilError(t, w.close(t.Context()))
+		assert.Equal(t, len(flushes), 0)
+	})
+
+	t.Run("close drains the rows and stops the ticker", func(t *testing.T) {
+		t.Parallel()
+		w, ticker, flushes, _, _ := newWriter(t, 10)
+		assert.NilError(t, w.add(t.Context(), 1))
+		assert.NilError(t, w.add(t.Context(), 2))
+		assert.NilError(t, w.close(t.Context()))
+		assert.DeepEqual(t, (<-flushes).rows, []int{1, 2})
+		<-ticker.stopped
+		assert.ErrorContains(t, w.add(t.Context(), 3), "bulk writer is closed")
+		assert.NilError(t, w.close(t.Context()))
+	})
+
+	t.Run("rows of a failed flush are passed to onError", func(t *testing.T) {
+		t.Parallel()
+		errFlush := errors.New("flush error")
+		w, _, flushes, failures, errorCalls := newWriter(t, 2)
+		failures <- errFlush
+		for _, row := range []int{1, 2, 3, 4} {
+			assert.NilError(t, w.add(t.Context(), row))
+		}
+		assert.NilError(t, w.close(t.Context()))
+		assert.DeepEqual(t, (<-flushes).rows, []int{1, 2})
+		assert.DeepEqual(t, (<-flushes).rows, []int{3, 4})
+		errorCall := <-errorCalls
+		assert.ErrorIs(t, errorCall.err, errFlush)
+		assert.DeepEqual(t, errorCall.rows, []int{1, 2})
+		assert.Equal(t, len(errorCalls), 0)
+	})
+
+	t.Run("close gives up when ctx is done", func(t *testing.T) {
+		t.Parallel()
+		errorCalls := make(chan flushed, 10)
+		started := make(chan struct{})
+		w := newBulkAsyncWriter(t.Context(), 1, time.Second, 0,
+			func(ctx context.Context, rows []int) error {
+				close(started)
+				// The flush blocks until close cancels it
+				<-ctx.Done()
+				return ctx.Err()
+			},
+			func(err error, rows []int) { errorCalls <- flushed{rows: rows, err: err} },
+			newFakeTicker().newTicker,
+		)
+		assert.NilError(t, w.add(t.Context(), 1))
+		<-started
+
+		ctx, cancel := context.WithCancel(t.Context())
+		cancel()
+		assert.ErrorIs(t, w.close(ctx), context.Canceled)
+		errorCall := <-errorCalls
+		assert.ErrorIs(t, errorCall.err, context.Canceled)
+		assert.DeepEqual(t, errorCall.rows, []int{1})
+	})
+
+	t.Run("close gives up when ctx is done while an add is blocked", func(t *testing.T) {
+		t.Parallel()
+		errorCalls := make(chan flushed, 10)
+		started := make(chan struct{})
+		w := newBulkAsyncWriter(t.Context(), 1, time.Second, 0,
+			func(ctx context.Context, rows []int) error {
+				close(started)
+				<-ctx.Done()
+				return ctx.Err()
+			},
+			func(err error, rows []int) { errorCalls <- flushed{rows: rows, err: err} },
+			newFakeTicker().newTicker,
+		)
+		assert.NilError(t, w.add(t.Context(), 1))
+		<-started
+		// The writer is flushing the first row and cannot receive the second one
+		addErr := make(chan error)
+		go func() { addErr <- w.add(t.Context(), 2) }()
+
+		ctx, cancel := context.WithTimeout(t.Context(), 100*time.Millisecond)
+		defer cancel()
+		closeErr := make(chan error)
+		go func() { closeErr <- w.close(ctx) }()
+		select {
+		case err := <-closeErr:
+			assert.ErrorIs(t, err, context.DeadlineExceeded)
+		case <-time.After(5 * time.Second):
+			t.Fatal("close did not give up when ctx was done")
+		}
+		assert.ErrorContains(t, <-addErr, "bulk writer is closed")
+		errorCall := <-errorCalls
+		assert.ErrorIs(t, errorCall.err, context.Canceled)
+		assert.DeepEqual(t, errorCall.rows, []int{1})
+	})
+
+	t.Run("add gives up when ctx is done", func(t *testing.T) {
+		t.Parallel()
+		block := make(chan struct{})
+		w := newBulkAsyncWriter(t.Context(), 1, time.Second, 0,
+			func(ctx context.Context, rows []int) error {
+				<-block
+				return nil
+			},
+			func(err error, rows []int) {},
+			newFakeTicker().newTicker,
+		)
+		assert.NilError(t, w.add(t.Context(), 1))
+		// The writer is flushing the first row and cannot receive the second one
+		ctx, cancel := context.WithTimeout(t.Context(), 10*time.Millisecond)
+		defer cancel()
+		assert.ErrorIs(t, w.add(ctx, 2), context.DeadlineExceeded)
+		close(block)
+		assert.NilError(t, w.close(t.Context()))
+	})
+}